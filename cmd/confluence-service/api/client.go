@@ -1,112 +1,120 @@
 package api
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
 
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
 )
 
-// WorkspaceCredentials holds connection info for one Atlassian instance
-type WorkspaceCredentials struct {
-	Site  string // e.g., "https://eso.atlassian.net/wiki"
-	Email string // e.g., "service@eso.com"
-	Token string // Atlassian API token
-}
+// Page sizes used when walking listings
+const (
+	childPageSize  = 100 // Child pages
+	searchPageSize = 100 // CQL search results
+	spacePageSize  = 100 // Spaces
+)
 
-// Client wraps HTTP client with Atlassian auth
+// Client is a Confluence REST client
 type Client struct {
-	creds      WorkspaceCredentials
-	httpClient *http.Client
+	*atlassian.Client
+	spaces sync.Map // Spaces by ID, as v2 pages reference them
 }
 
 // NewClient creates an authenticated Confluence client
-func NewClient(creds WorkspaceCredentials) *Client {
-	return &Client{
-		creds:      creds,
-		httpClient: &http.Client{},
-	}
+func NewClient(creds atlassian.Credentials) *Client {
+	return NewClientWithHTTPClient(creds, nil)
 }
 
-// authHeader returns the Basic auth header value
-func (c *Client) authHeader() string {
-	credentials := fmt.Sprintf("%s:%s", c.creds.Email, c.creds.Token)
-	encoded := base64.StdEncoding.EncodeToString([]byte(credentials))
-	return "Basic " + encoded
+// NewClientWithHTTPClient creates an authenticated Confluence client that sends
+// requests through a shared HTTP client
+func NewClientWithHTTPClient(creds atlassian.Credentials, httpClient *http.Client) *Client {
+	return &Client{
+		Client: atlassian.NewClient(creds, httpClient),
+	}
 }
 
 // GetPage fetches a page by ID with body content
 func (c *Client) GetPage(pageID string) (*models.ConfluencePage, error) {
-	url := fmt.Sprintf("%s/rest/api/content/%s?expand=body.storage,version",
-		c.creds.Site, pageID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+	if c.useV2("get_page") {
+		return c.getPageV2(pageID)
 	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get page %s: %s", pageID, string(body))
-	}
+	url := fmt.Sprintf("%s/rest/api/content/%s?expand=body.storage,version,space", c.Site(), pageID)
 
 	var page models.ConfluencePage
-	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+	if err := c.GetJSON(url, fmt.Sprintf("get page %s", pageID), &page); err != nil {
 		return nil, err
 	}
 
 	return &page, nil
 }
 
-// GetChildren returns all direct child pages of a parent page
-func (c *Client) GetChildren(pageID string) ([]models.ConfluencePage, error) {
-	url := fmt.Sprintf("%s/rest/api/content/%s/child/page?expand=version",
-		c.creds.Site, pageID)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// GetChildren lists the direct child pages of a parent page, following the response's
+// next links across pages as the page request selects
+func (c *Client) GetChildren(pageID string, page atlassian.PageRequest) (*models.PageList, error) {
+	if c.useV2("get_children") {
+		return c.getChildrenV2(pageID, page)
 	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	children := &models.PageList{Results: []models.ConfluencePage{}}
+
+	next, err := page.Collect(childPageSize, func(cursor atlassian.Cursor, limit int) (int, *atlassian.Cursor, error) {
+		url, err := c.pageURL(cursor, limit, atlassian.WithQuery(fmt.Sprintf("%s/rest/api/content/%s/child/page", c.Site(), pageID), url.Values{
+			"expand": {"version"},
+			"start":  {strconv.Itoa(cursor.Start)},
+			"limit":  {strconv.Itoa(limit)},
+		}))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		var resp struct {
+			Results []models.ConfluencePage `json:"results"`
+			Links   nextLinks               `json:"_links"`
+		}
+		if err := c.GetJSON(url, fmt.Sprintf("get children of %s", pageID), &resp); err != nil {
+			return 0, nil, err
+		}
+
+		children.Results = append(children.Results, resp.Results...)
+		return len(resp.Results), resp.Links.cursor(), nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get children of %s: %s", pageID, string(body))
-	}
+	children.NextCursor = atlassian.NextCursor(next)
+	return children, nil
+}
+
+// FindPageByTitle returns the page with a title in a space, or nil when there is none
+func (c *Client) FindPageByTitle(spaceKey, title string) (*models.ConfluencePage, error) {
+	url := atlassian.WithQuery(fmt.Sprintf("%s/rest/api/content", c.Site()), url.Values{
+		"type":     {"page"},
+		"spaceKey": {spaceKey},
+		"title":    {title},
+		"expand":   {"version"},
+	})
 
 	var result struct {
 		Results []models.ConfluencePage `json:"results"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := c.GetJSON(url, fmt.Sprintf("find page '%s' in %s", title, spaceKey), &result); err != nil {
 		return nil, err
 	}
 
-	return result.Results, nil
+	if len(result.Results) == 0 {
+		return nil, nil
+	}
+	return &result.Results[0], nil
 }
 
 // CreatePage creates a new page in the specified space
 func (c *Client) CreatePage(spaceKey, title, body string, parentID *string) (*models.ConfluencePage, error) {
-	url := fmt.Sprintf("%s/rest/api/content", c.creds.Site)
-
 	payload := models.CreatePageRequest{
 		Type:  "page",
 		Title: title,
@@ -123,128 +131,199 @@ func (c *Client) CreatePage(spaceKey, title, body string, parentID *string) (*mo
 		payload.Ancestors = []models.AncestorRef{{ID: *parentID}}
 	}
 
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
+	var page models.ConfluencePage
+	if err := c.SendJSON("POST", fmt.Sprintf("%s/rest/api/content", c.Site()),
+		fmt.Sprintf("create page '%s'", title), payload, &page); err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonPayload))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	return &page, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+// UpdatePage replaces a page's title and body, moving it under parentID when that is
+// set. version is the new version number, one more than the page's current version;
+// Confluence answers 409 when it is not.
+func (c *Client) UpdatePage(pageID, title, body string, version int, versionComment string, parentID *string) (*models.ConfluencePage, error) {
+	payload := models.UpdatePageRequest{
+		ID:    pageID,
+		Type:  "page",
+		Title: title,
+		Body: models.BodyContent{
+			Storage: models.StorageContent{
+				Value:          body,
+				Representation: "storage",
+			},
+		},
+		Version: models.VersionInfo{
+			Number:  version,
+			Message: versionComment,
+		},
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to create page '%s': %s", title, string(body))
+	if parentID != nil {
+		payload.Ancestors = []models.AncestorRef{{ID: *parentID}}
 	}
 
 	var page models.ConfluencePage
-	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+	if err := c.SendJSON("PUT", fmt.Sprintf("%s/rest/api/content/%s", c.Site(), pageID),
+		fmt.Sprintf("update page %s", pageID), payload, &page); err != nil {
 		return nil, err
 	}
 
 	return &page, nil
 }
 
-// SearchPages searches for pages using CQL
-func (c *Client) SearchPages(cql string, limit int) (*models.SearchResults, error) {
-	url := fmt.Sprintf("%s/rest/api/content/search?cql=%s&limit=%d",
-		c.creds.Site, cql, limit)
+// GetAncestorIDs returns the IDs of a page's ancestors, from the space's root page down
+func (c *Client) GetAncestorIDs(pageID string) ([]string, error) {
+	url := fmt.Sprintf("%s/rest/api/content/%s?expand=ancestors", c.Site(), pageID)
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+	var page struct {
+		Ancestors []models.AncestorRef `json:"ancestors"`
 	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+	if err := c.GetJSON(url, fmt.Sprintf("get ancestors of %s", pageID), &page); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to search: %s", string(body))
+	ids := make([]string, len(page.Ancestors))
+	for i, a := range page.Ancestors {
+		ids[i] = a.ID
 	}
+	return ids, nil
+}
 
-	var results models.SearchResults
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+// SearchPages searches for pages using CQL, following the response's next links
+// across pages as the page request selects
+func (c *Client) SearchPages(cql string, page atlassian.PageRequest) (*models.SearchResults, error) {
+	results := &models.SearchResults{Results: []models.ConfluencePage{}, Start: page.Cursor.Start, Limit: page.Limit}
+
+	next, err := page.Collect(searchPageSize, func(cursor atlassian.Cursor, limit int) (int, *atlassian.Cursor, error) {
+		url, err := c.pageURL(cursor, limit, atlassian.WithQuery(fmt.Sprintf("%s/rest/api/content/search", c.Site()), url.Values{
+			"cql":   {cql},
+			"limit": {strconv.Itoa(limit)},
+			"start": {strconv.Itoa(cursor.Start)},
+		}))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		var resp struct {
+			Results []models.ConfluencePage `json:"results"`
+			Links   nextLinks               `json:"_links"`
+		}
+		if err := c.GetJSON(url, "search", &resp); err != nil {
+			return 0, nil, err
+		}
+
+		results.Results = append(results.Results, resp.Results...)
+		return len(resp.Results), resp.Links.cursor(), nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return &results, nil
+	results.Size = len(results.Results)
+	results.NextCursor = atlassian.NextCursor(next)
+	return results, nil
 }
 
-// ListSpaces lists all spaces in the workspace
-func (c *Client) ListSpaces(limit int) ([]models.ConfluenceSpace, error) {
-	url := fmt.Sprintf("%s/rest/api/space?limit=%d", c.creds.Site, limit)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// ListSpaces lists the spaces in the workspace, following the response's next links
+// across pages as the page request selects
+func (c *Client) ListSpaces(page atlassian.PageRequest) (*models.SpaceList, error) {
+	if c.useV2("list_spaces") {
+		return c.listSpacesV2(page)
 	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	spaces := &models.SpaceList{Results: []models.ConfluenceSpace{}}
+
+	next, err := page.Collect(spacePageSize, func(cursor atlassian.Cursor, limit int) (int, *atlassian.Cursor, error) {
+		url, err := c.pageURL(cursor, limit, fmt.Sprintf("%s/rest/api/space?limit=%d&start=%d", c.Site(), limit, cursor.Start))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		var resp struct {
+			Results []models.ConfluenceSpace `json:"results"`
+			Links   nextLinks                `json:"_links"`
+		}
+		if err := c.GetJSON(url, "list spaces", &resp); err != nil {
+			return 0, nil, err
+		}
+
+		spaces.Results = append(spaces.Results, resp.Results...)
+		return len(resp.Results), resp.Links.cursor(), nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to list spaces: %s", string(body))
-	}
+	spaces.NextCursor = atlassian.NextCursor(next)
+	return spaces, nil
+}
 
-	var result struct {
-		Results []models.ConfluenceSpace `json:"results"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+// nextLinks holds the link to the next page of a v1 listing
+type nextLinks struct {
+	Next string `json:"next"`
+}
+
+// cursor returns the cursor of the next page, or nil after the last page
+func (l nextLinks) cursor() *atlassian.Cursor {
+	if l.Next == "" {
+		return nil
 	}
+	return &atlassian.Cursor{Next: l.Next}
+}
 
-	return result.Results, nil
+// pageURL returns the URL of the page at a cursor: its next link when it has one,
+// otherwise first
+func (c *Client) pageURL(cursor atlassian.Cursor, limit int, first string) (string, error) {
+	if cursor.Next == "" {
+		return first, nil
+	}
+	return cursor.NextURL(c.Site(), limit)
 }
 
 // GetSpace gets details about a specific space
 func (c *Client) GetSpace(spaceKey string) (*models.ConfluenceSpace, error) {
-	url := fmt.Sprintf("%s/rest/api/space/%s", c.creds.Site, spaceKey)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+	if c.useV2("get_space") {
+		return c.getSpaceV2(spaceKey)
 	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
+	var space models.ConfluenceSpace
+	if err := c.GetJSON(fmt.Sprintf("%s/rest/api/space/%s", c.Site(), spaceKey),
+		fmt.Sprintf("get space %s", spaceKey), &space); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get space %s: %s", spaceKey, string(body))
+	return &space, nil
+}
+
+// GetUser looks up a user by Cloud account ID, or by user key on Data Center
+func (c *Client) GetUser(accountID, userKey string) (*models.ConfluenceUser, error) {
+	params := url.Values{"accountId": {accountID}}
+	if accountID == "" {
+		params = url.Values{"key": {userKey}}
 	}
 
-	var space models.ConfluenceSpace
-	if err := json.NewDecoder(resp.Body).Decode(&space); err != nil {
+	var user models.ConfluenceUser
+	if err := c.GetJSON(atlassian.WithQuery(fmt.Sprintf("%s/rest/api/user", c.Site()), params),
+		"get user", &user); err != nil {
 		return nil, err
 	}
 
-	return &space, nil
+	return &user, nil
 }
 
+// GetCurrentUser returns the user the client is authenticated as
+func (c *Client) GetCurrentUser() (*models.ConfluenceUser, error) {
+	var user models.ConfluenceUser
+	if err := c.GetJSON(fmt.Sprintf("%s/rest/api/user/current", c.Site()), "get current user", &user); err != nil {
+		return nil, err
+	}
+
+	// Confluence answers anonymously instead of rejecting requests with bad credentials
+	if user.Type == "anonymous" {
+		return nil, fmt.Errorf("credentials were not accepted: authenticated as anonymous user")
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,13 @@
+package api
+
+import (
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+)
+
+// ClientPool reuses Confluence clients per user/workspace on a shared transport
+type ClientPool = atlassian.Pool[*Client]
+
+// NewClientPool creates a Confluence client pool
+func NewClientPool(opts atlassian.ClientOptions) *ClientPool {
+	return atlassian.NewPool(opts, NewClientWithHTTPClient)
+}
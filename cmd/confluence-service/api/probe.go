@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// Probe checks authentication, space access and permitted operations with live requests.
+// It never returns an error; failures are reported in the health status.
+func (c *Client) Probe() *models.ProductHealth {
+	health := &models.ProductHealth{
+		Product:  models.ProductConfluence,
+		BaseURL:  c.Site(),
+		AuthType: c.Credentials().AuthType,
+	}
+
+	// Authentication and identity; operations lists what the account may do (Cloud only)
+	resp, body, latency, err := c.probeGet(fmt.Sprintf("%s/rest/api/user/current?expand=operations", c.Site()))
+	if err != nil {
+		health.Status = models.HealthUnreachable
+		if errors.Is(err, atlassian.ErrAuthorize) {
+			health.Status = models.HealthAuthFailed
+		}
+		health.Error = err.Error()
+		return health
+	}
+	health.LatencyMS = latency.Milliseconds()
+	health.RateLimit = models.RateLimitFromHeaders(resp.Header)
+	health.Status = models.HealthFromStatusCode(resp.StatusCode)
+	if health.Status != models.HealthOK {
+		health.Error = fmt.Sprintf("GET /rest/api/user/current returned status %d", resp.StatusCode)
+		return health
+	}
+
+	var user struct {
+		models.ConfluenceUser
+		Operations []struct {
+			Operation  string `json:"operation"`
+			TargetType string `json:"targetType"`
+		} `json:"operations"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil {
+		health.Status = models.HealthUnavailable
+		health.Error = "unexpected response from /rest/api/user/current"
+		return health
+	}
+	if user.Type == "anonymous" {
+		health.Status = models.HealthAuthFailed
+		health.Error = "credentials were not accepted: authenticated as anonymous user"
+		return health
+	}
+
+	accountID := user.AccountID
+	if accountID == "" {
+		accountID = user.Username
+	}
+	health.Account = &models.CredentialCheck{
+		Product:     models.ProductConfluence,
+		BaseURL:     c.Site(),
+		AccountID:   accountID,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+	}
+
+	if len(user.Operations) > 0 {
+		health.Permissions = make(map[string]bool, len(user.Operations))
+		for _, op := range user.Operations {
+			health.Permissions[op.Operation+":"+op.TargetType] = true
+		}
+	}
+
+	var problems []string
+
+	// Space access
+	resp, body, _, err = c.probeGet(fmt.Sprintf("%s/rest/api/space?limit=1", c.Site()))
+	if err == nil && resp.StatusCode == http.StatusOK {
+		var spaces struct {
+			Size int `json:"size"`
+		}
+		if json.Unmarshal(body, &spaces) == nil && spaces.Size == 0 {
+			problems = append(problems, "account cannot see any spaces")
+		}
+	} else {
+		problems = append(problems, "spaces could not be listed")
+	}
+
+	if health.RateLimit != nil && health.RateLimit.NearLimit {
+		problems = append(problems, "rate limit nearly exhausted")
+	}
+
+	if len(problems) > 0 {
+		health.Status = models.HealthDegraded
+		health.Error = strings.Join(problems, "; ")
+	}
+
+	return health
+}
+
+// probeGet performs an authenticated GET and returns the raw response, body and latency
+func (c *Client) probeGet(url string) (*http.Response, []byte, time.Duration, error) {
+	req, err := c.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	// Report throttling as it is rather than waiting it out
+	req = atlassian.NoRetry(req)
+
+	start := time.Now()
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	latency := time.Since(start)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return resp, body, latency, nil
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// SearchFilter describes a structured search. Set fields narrow the results; all of
+// them must match.
+type SearchFilter struct {
+	Text          string    // Words in the title or body
+	SpaceKeys     []string  // Spaces the content may be in
+	Labels        []string  // Labels the content must all have
+	Type          string    // Content type: page, blogpost, comment or attachment
+	Author        string    // Creator's account ID, or username on Data Center
+	ModifiedSince time.Time // Earliest last-modified time, in UTC to the minute
+	Ancestor      string    // ID of a page the content must be below
+}
+
+// CQL returns the filter as a CQL query, newest content first. Values are quoted,
+// so they cannot change the query's structure. A filter with no fields set is an
+// error rather than a query for all content.
+func (f SearchFilter) CQL() (string, error) {
+	var clauses []string
+	if f.Text != "" {
+		clauses = append(clauses, "text ~ "+QuoteCQL(f.Text))
+	}
+	if len(f.SpaceKeys) > 0 {
+		clauses = append(clauses, "space in ("+quoteCQLList(f.SpaceKeys)+")")
+	}
+	for _, label := range f.Labels {
+		clauses = append(clauses, "label = "+QuoteCQL(label))
+	}
+	if f.Type != "" {
+		clauses = append(clauses, "type = "+QuoteCQL(f.Type))
+	}
+	if f.Author != "" {
+		clauses = append(clauses, "creator = "+QuoteCQL(f.Author))
+	}
+	if !f.ModifiedSince.IsZero() {
+		// CQL dates carry no offset, so a time in another zone would silently shift
+		if _, offset := f.ModifiedSince.Zone(); offset != 0 {
+			return "", errors.New("modified_since must be in UTC (Z or +00:00); CQL dates have no time zone offset")
+		}
+		clauses = append(clauses, "lastmodified >= "+QuoteCQL(f.ModifiedSince.Format("2006-01-02 15:04")))
+	}
+	if f.Ancestor != "" {
+		clauses = append(clauses, "ancestor = "+QuoteCQL(f.Ancestor))
+	}
+
+	if len(clauses) == 0 {
+		return "", errors.New("the search has no criteria")
+	}
+	return strings.Join(clauses, " AND ") + " order by lastmodified desc", nil
+}
+
+// QuoteCQL returns s as a CQL string literal
+func QuoteCQL(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// quoteCQLList returns values as a comma-separated list of CQL string literals
+func quoteCQLList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = QuoteCQL(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// searchResult is one hit of /rest/api/search
+type searchResult struct {
+	Content struct {
+		ID    string `json:"id"`
+		Type  string `json:"type"`
+		Space struct {
+			Key  string `json:"key"`
+			Name string `json:"name"`
+		} `json:"space"`
+	} `json:"content"`
+	Title                 string `json:"title"`
+	Excerpt               string `json:"excerpt"`
+	URL                   string `json:"url"`
+	LastModified          string `json:"lastModified"`
+	ResultGlobalContainer struct {
+		Title string `json:"title"`
+	} `json:"resultGlobalContainer"`
+}
+
+// Search runs a CQL query through the site search, which returns an excerpt, the
+// space, the last-modified time and the URL of each hit. Hit URLs are made absolute
+// with browseURL, the site people open in a browser, since the API site may be the
+// OAuth 2.0 gateway.
+func (c *Client) Search(cql, browseURL string, page atlassian.PageRequest) (*models.SearchHits, error) {
+	hits := &models.SearchHits{Results: []models.SearchHit{}}
+
+	next, err := page.Collect(searchPageSize, func(cursor atlassian.Cursor, limit int) (int, *atlassian.Cursor, error) {
+		url, err := c.pageURL(cursor, limit, atlassian.WithQuery(fmt.Sprintf("%s/rest/api/search", c.Site()), url.Values{
+			"cql":     {cql},
+			"limit":   {strconv.Itoa(limit)},
+			"start":   {strconv.Itoa(cursor.Start)},
+			"excerpt": {"indexed"},
+			"expand":  {"content.space"},
+		}))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		var resp struct {
+			Results   []searchResult `json:"results"`
+			TotalSize int            `json:"totalSize"`
+			Links     nextLinks      `json:"_links"`
+		}
+		if err := c.GetJSON(url, "search", &resp); err != nil {
+			return 0, nil, err
+		}
+
+		for _, r := range resp.Results {
+			hits.Results = append(hits.Results, searchHit(r, browseURL))
+		}
+		hits.TotalSize = resp.TotalSize
+		return len(resp.Results), resp.Links.cursor(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits.Size = len(hits.Results)
+	hits.NextCursor = atlassian.NextCursor(next)
+	return hits, nil
+}
+
+// searchHit converts a site search result; its URL is relative to browseURL
+func searchHit(r searchResult, browseURL string) models.SearchHit {
+	hit := models.SearchHit{
+		ID:           r.Content.ID,
+		Type:         r.Content.Type,
+		Title:        r.Title,
+		Excerpt:      strings.Join(strings.Fields(html.UnescapeString(r.Excerpt)), " "),
+		LastModified: r.LastModified,
+	}
+	if r.Content.Space.Key != "" {
+		hit.Space = &models.SpaceRef{Key: r.Content.Space.Key, Name: r.Content.Space.Name}
+	} else if r.ResultGlobalContainer.Title != "" {
+		hit.Space = &models.SpaceRef{Name: r.ResultGlobalContainer.Title}
+	}
+	if r.URL != "" {
+		hit.URL = browseURL + r.URL
+	}
+	return hit
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchFilterModifiedSince(t *testing.T) {
+	utc := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	cql, err := SearchFilter{ModifiedSince: utc}.CQL()
+	if err != nil {
+		t.Fatalf("CQL: %v", err)
+	}
+	if want := `lastmodified >= "2024-05-01 09:30" order by lastmodified desc`; cql != want {
+		t.Fatalf("CQL = %q, want %q", cql, want)
+	}
+
+	// An offset of zero is UTC however it is written
+	zero, _ := time.Parse(time.RFC3339, "2024-05-01T09:30:00+00:00")
+	if _, err := (SearchFilter{ModifiedSince: zero}).CQL(); err != nil {
+		t.Fatalf("CQL of a +00:00 time: %v", err)
+	}
+
+	// Other offsets would be dropped by the CQL date format, so they are refused
+	offset, _ := time.Parse(time.RFC3339, "2024-05-01T09:30:00+02:00")
+	if _, err := (SearchFilter{ModifiedSince: offset}).CQL(); err == nil {
+		t.Fatal("CQL of a +02:00 time succeeded, want an error")
+	}
+}
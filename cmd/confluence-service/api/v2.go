@@ -0,0 +1,241 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// v2Operations are the operations served by the v2 REST API on Cloud, where their
+// v1 endpoints are deprecated. Data Center has no v2 API, and operations without a
+// v2 equivalent, such as CQL search, always use v1.
+var v2Operations = map[string]bool{
+	"get_page":     true,
+	"get_children": true,
+	"list_spaces":  true,
+	"get_space":    true,
+}
+
+// useV2 reports whether an operation goes to the v2 REST API
+func (c *Client) useV2(operation string) bool {
+	return v2Operations[operation] && !c.IsDataCenter()
+}
+
+// v2Base returns the v2 REST API root
+func (c *Client) v2Base() string {
+	return c.Site() + "/api/v2"
+}
+
+// v2Page is a page as the v2 API returns it
+type v2Page struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	SpaceID string `json:"spaceId"`
+	Version struct {
+		Number    int    `json:"number"`
+		Message   string `json:"message"`
+		MinorEdit bool   `json:"minorEdit"`
+	} `json:"version"`
+	Body struct {
+		Storage models.StorageContent `json:"storage"`
+	} `json:"body"`
+	Links models.PageLinks `json:"_links"`
+}
+
+// page converts a v2 page; the space is filled in separately
+func (p v2Page) page() models.ConfluencePage {
+	return models.ConfluencePage{
+		ID:    p.ID,
+		Title: p.Title,
+		Version: models.VersionInfo{
+			Number:    p.Version.Number,
+			Message:   p.Version.Message,
+			MinorEdit: p.Version.MinorEdit,
+		},
+		Body:  models.PageBody{Storage: p.Body.Storage},
+		Links: p.Links,
+	}
+}
+
+// v2Space is a space as the v2 API returns it
+type v2Space struct {
+	ID          string `json:"id"`
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description struct {
+		Plain struct {
+			Value string `json:"value"`
+		} `json:"plain"`
+	} `json:"description"`
+}
+
+// space converts a v2 space
+func (s v2Space) space() models.ConfluenceSpace {
+	id, _ := strconv.Atoi(s.ID)
+	return models.ConfluenceSpace{
+		ID:          id,
+		Key:         s.Key,
+		Name:        s.Name,
+		Type:        s.Type,
+		Description: s.Description.Plain.Value,
+	}
+}
+
+// v2Links holds the link to the next page of a v2 listing
+type v2Links struct {
+	Next string `json:"next"`
+}
+
+// cursor returns the cursor of the next page, or nil after the last page. Only the
+// cursor parameter of the next link is kept; the link's path includes the site's
+// context path, which Site already has.
+func (l v2Links) cursor() *atlassian.Cursor {
+	if l.Next == "" {
+		return nil
+	}
+	next, err := url.Parse(l.Next)
+	if err != nil || next.Query().Get("cursor") == "" {
+		return nil
+	}
+	return &atlassian.Cursor{Token: next.Query().Get("cursor")}
+}
+
+// v2URL returns the URL of a v2 listing page: path with params, the page size and,
+// past the first page, the cursor
+func (c *Client) v2URL(path string, params url.Values, cursor atlassian.Cursor, limit int) string {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("limit", strconv.Itoa(limit))
+	if cursor.Token != "" {
+		params.Set("cursor", cursor.Token)
+	}
+	return atlassian.WithQuery(c.v2Base()+path, params)
+}
+
+// getPageV2 fetches a page with its storage body from the v2 API
+func (c *Client) getPageV2(pageID string) (*models.ConfluencePage, error) {
+	url := atlassian.WithQuery(fmt.Sprintf("%s/pages/%s", c.v2Base(), pageID), url.Values{
+		"body-format": {"storage"},
+	})
+
+	var resp v2Page
+	if err := c.GetJSON(url, fmt.Sprintf("get page %s", pageID), &resp); err != nil {
+		return nil, err
+	}
+
+	// v2 pages reference their space by ID; callers expect its key
+	page := resp.page()
+	if resp.SpaceID != "" {
+		space, err := c.spaceByID(resp.SpaceID)
+		if err != nil {
+			return nil, err
+		}
+		page.Space = models.SpaceRef{Key: space.Key, Name: space.Name, ID: resp.SpaceID}
+	}
+
+	return &page, nil
+}
+
+// spaceByID fetches a space by its ID from the v2 API, remembering it for later pages
+func (c *Client) spaceByID(spaceID string) (models.ConfluenceSpace, error) {
+	if space, ok := c.spaces.Load(spaceID); ok {
+		return space.(models.ConfluenceSpace), nil
+	}
+
+	var resp v2Space
+	if err := c.GetJSON(fmt.Sprintf("%s/spaces/%s", c.v2Base(), spaceID),
+		fmt.Sprintf("get space %s", spaceID), &resp); err != nil {
+		return models.ConfluenceSpace{}, err
+	}
+
+	space := resp.space()
+	c.spaces.Store(spaceID, space)
+	return space, nil
+}
+
+// getChildrenV2 lists the child pages of a parent page from the v2 API as the page
+// request selects
+func (c *Client) getChildrenV2(pageID string, page atlassian.PageRequest) (*models.PageList, error) {
+	children := &models.PageList{Results: []models.ConfluencePage{}}
+
+	next, err := page.Collect(childPageSize, func(cursor atlassian.Cursor, limit int) (int, *atlassian.Cursor, error) {
+		var resp struct {
+			Results []v2Page `json:"results"`
+			Links   v2Links  `json:"_links"`
+		}
+		if err := c.GetJSON(c.v2URL(fmt.Sprintf("/pages/%s/children", pageID), nil, cursor, limit),
+			fmt.Sprintf("get children of %s", pageID), &resp); err != nil {
+			return 0, nil, err
+		}
+
+		for _, p := range resp.Results {
+			children.Results = append(children.Results, p.page())
+		}
+		return len(resp.Results), resp.Links.cursor(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	children.NextCursor = atlassian.NextCursor(next)
+	return children, nil
+}
+
+// listSpacesV2 lists spaces from the v2 API as the page request selects
+func (c *Client) listSpacesV2(page atlassian.PageRequest) (*models.SpaceList, error) {
+	spaces := &models.SpaceList{Results: []models.ConfluenceSpace{}}
+
+	next, err := page.Collect(spacePageSize, func(cursor atlassian.Cursor, limit int) (int, *atlassian.Cursor, error) {
+		var resp struct {
+			Results []v2Space `json:"results"`
+			Links   v2Links   `json:"_links"`
+		}
+		if err := c.GetJSON(c.v2URL("/spaces", url.Values{"description-format": {"plain"}}, cursor, limit),
+			"list spaces", &resp); err != nil {
+			return 0, nil, err
+		}
+
+		for _, s := range resp.Results {
+			spaces.Results = append(spaces.Results, s.space())
+		}
+		return len(resp.Results), resp.Links.cursor(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spaces.NextCursor = atlassian.NextCursor(next)
+	return spaces, nil
+}
+
+// getSpaceV2 fetches a space by key from the v2 API
+func (c *Client) getSpaceV2(spaceKey string) (*models.ConfluenceSpace, error) {
+	url := atlassian.WithQuery(c.v2Base()+"/spaces", url.Values{
+		"keys":               {spaceKey},
+		"description-format": {"plain"},
+	})
+
+	var resp struct {
+		Results []v2Space `json:"results"`
+	}
+	if err := c.GetJSON(url, fmt.Sprintf("get space %s", spaceKey), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, &atlassian.HTTPError{
+			Op:         fmt.Sprintf("get space %s", spaceKey),
+			StatusCode: http.StatusNotFound,
+			Code:       models.ErrCodeNotFound,
+			Messages:   []string{"space not found"},
+		}
+	}
+
+	space := resp.Results[0].space()
+	return &space, nil
+}
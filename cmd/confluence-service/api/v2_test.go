@@ -0,0 +1,275 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// fakeCloud serves responses recorded from the Confluence Cloud v2 API, under the
+// /wiki context path like a real site
+type fakeCloud struct {
+	t        *testing.T
+	mu       sync.Mutex
+	requests []*url.URL
+}
+
+// newV2Client returns a Cloud client talking to a fake site
+func newV2Client(t *testing.T) (*Client, *fakeCloud) {
+	f := &fakeCloud{t: t}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return NewClient(atlassian.Credentials{Site: srv.URL + "/wiki", Email: "user@example.com", Token: "token"}), f
+}
+
+func (f *fakeCloud) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL)
+	f.mu.Unlock()
+
+	query := r.URL.Query()
+	fixture := ""
+	switch r.URL.Path {
+	case "/wiki/api/v2/pages/393217":
+		if query.Get("body-format") == "storage" {
+			fixture = "page.json"
+		}
+	case "/wiki/api/v2/spaces/98305":
+		fixture = "space.json"
+	case "/wiki/api/v2/pages/393217/children":
+		fixture = "children_1.json"
+		if query.Get("cursor") == "eyJpZCI6IjM5MzIxOSJ9" {
+			fixture = "children_2.json"
+		}
+	case "/wiki/api/v2/spaces":
+		switch {
+		case query.Get("keys") == "ENG":
+			fixture = "spaces_by_key.json"
+		case query.Has("keys"):
+			fixture = "spaces_empty.json"
+		case query.Get("cursor") == "eyJpZCI6IjEzMTA3MyJ9":
+			fixture = "spaces_2.json"
+		default:
+			fixture = "spaces_1.json"
+		}
+	}
+	if fixture == "" {
+		http.Error(w, `{"errors":[{"status":404,"code":"NOT_FOUND","title":"Not Found"}]}`, http.StatusNotFound)
+		return
+	}
+
+	body, err := os.ReadFile(filepath.Join("testdata", "v2", fixture))
+	if err != nil {
+		f.t.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// paths returns the paths requested so far, in order
+func (f *fakeCloud) paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	paths := make([]string, len(f.requests))
+	for i, u := range f.requests {
+		paths[i] = u.Path
+	}
+	return paths
+}
+
+// query returns the query of the i-th request
+func (f *fakeCloud) query(i int) url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[i].Query()
+}
+
+func TestGetPageV2(t *testing.T) {
+	client, fake := newV2Client(t)
+
+	page, err := client.GetPage("393217")
+	if err != nil {
+		t.Fatalf("GetPage: %v", err)
+	}
+
+	want := &models.ConfluencePage{
+		ID:      "393217",
+		Title:   "Release checklist",
+		Version: models.VersionInfo{Number: 7, Message: "Add rollback steps", MinorEdit: true},
+		Body: models.PageBody{Storage: models.StorageContent{
+			Value:          "<h2>Before</h2><p>Freeze <strong>main</strong>.</p>",
+			Representation: "storage",
+		}},
+		Space: models.SpaceRef{Key: "ENG", Name: "Engineering", ID: "98305"},
+		Links: models.PageLinks{WebUI: "/spaces/ENG/pages/393217/Release+checklist"},
+	}
+	if !reflect.DeepEqual(page, want) {
+		t.Fatalf("GetPage =\n%+v\nwant\n%+v", page, want)
+	}
+
+	// The space is looked up once and remembered for later pages
+	if _, err := client.GetPage("393217"); err != nil {
+		t.Fatalf("GetPage: %v", err)
+	}
+	wantPaths := []string{"/wiki/api/v2/pages/393217", "/wiki/api/v2/spaces/98305", "/wiki/api/v2/pages/393217"}
+	if got := fake.paths(); !reflect.DeepEqual(got, wantPaths) {
+		t.Fatalf("requests = %v, want %v", got, wantPaths)
+	}
+}
+
+func TestGetPageV2NotFound(t *testing.T) {
+	client, _ := newV2Client(t)
+
+	_, err := client.GetPage("1")
+	if atlassian.ErrorCode(err) != models.ErrCodeNotFound {
+		t.Fatalf("GetPage of a missing page = %v, want a not found error", err)
+	}
+}
+
+func TestGetChildrenV2(t *testing.T) {
+	client, fake := newV2Client(t)
+
+	children, err := client.GetChildren("393217", atlassian.PageRequest{Limit: atlassian.MaxCollected, All: true})
+	if err != nil {
+		t.Fatalf("GetChildren: %v", err)
+	}
+
+	var titles []string
+	for _, c := range children.Results {
+		titles = append(titles, c.ID+" "+c.Title)
+	}
+	want := []string{"393218 Staging rollout", "393219 Production rollout", "393220 Rollback"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Fatalf("children = %v, want %v", titles, want)
+	}
+	if children.NextCursor != "" {
+		t.Fatalf("fetch-all next_cursor = %q, want none", children.NextCursor)
+	}
+
+	// The second page is requested with the cursor of the first page's next link, at
+	// the client's site rather than the link's path
+	if n := len(fake.paths()); n != 2 {
+		t.Fatalf("made %d requests, want 2", n)
+	}
+	first, second := fake.query(0), fake.query(1)
+	if first.Has("cursor") || first.Get("limit") != strconv.Itoa(childPageSize) {
+		t.Errorf("first page query = %v, want limit %d and no cursor", first, childPageSize)
+	}
+	if second.Get("cursor") != "eyJpZCI6IjM5MzIxOSJ9" || second.Get("limit") != strconv.Itoa(childPageSize) {
+		t.Errorf("second page query = %v, want the next link's cursor", second)
+	}
+}
+
+func TestGetChildrenV2OnePage(t *testing.T) {
+	client, fake := newV2Client(t)
+
+	// A single page is capped at the page size and continued with the returned cursor
+	first, err := client.GetChildren("393217", atlassian.PageRequest{Limit: 5000})
+	if err != nil {
+		t.Fatalf("GetChildren: %v", err)
+	}
+	if len(first.Results) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page = %d children with next_cursor %q, want 2 and a cursor", len(first.Results), first.NextCursor)
+	}
+	if q := fake.query(0); q.Get("limit") != strconv.Itoa(childPageSize) {
+		t.Errorf("first page query = %v, want limit %d", q, childPageSize)
+	}
+
+	cursor, err := atlassian.DecodeCursor(first.NextCursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	second, err := client.GetChildren("393217", atlassian.PageRequest{Cursor: cursor, Limit: 50})
+	if err != nil {
+		t.Fatalf("GetChildren: %v", err)
+	}
+	if len(second.Results) != 1 || second.Results[0].ID != "393220" || second.NextCursor != "" {
+		t.Fatalf("last page = %+v, want only 393220 and no next_cursor", second)
+	}
+}
+
+func TestListSpacesV2(t *testing.T) {
+	client, fake := newV2Client(t)
+
+	// One page at a time, continued with the returned cursor
+	first, err := client.ListSpaces(atlassian.PageRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListSpaces: %v", err)
+	}
+	wantFirst := []models.ConfluenceSpace{
+		{ID: 98305, Key: "ENG", Name: "Engineering", Type: "global", Description: "Engineering handbook and runbooks"},
+		{ID: 131073, Key: "~5b10ac8d82e05b22cc7d4ef5", Name: "Dana Reyes", Type: "personal"},
+	}
+	if !reflect.DeepEqual(first.Results, wantFirst) {
+		t.Fatalf("first page = %+v, want %+v", first.Results, wantFirst)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("first page has no next_cursor")
+	}
+	if q := fake.query(0); q.Get("limit") != "2" || q.Get("description-format") != "plain" {
+		t.Errorf("first page query = %v, want limit 2 and plain descriptions", q)
+	}
+
+	cursor, err := atlassian.DecodeCursor(first.NextCursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	second, err := client.ListSpaces(atlassian.PageRequest{Cursor: cursor, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListSpaces: %v", err)
+	}
+	if len(second.Results) != 1 || second.Results[0].Key != "OPS" || second.NextCursor != "" {
+		t.Fatalf("last page = %+v, want only OPS and no next_cursor", second)
+	}
+
+	// Fetch-all walks both pages in one call
+	all, err := client.ListSpaces(atlassian.PageRequest{Limit: 10, All: true})
+	if err != nil {
+		t.Fatalf("ListSpaces: %v", err)
+	}
+	if len(all.Results) != 3 || all.NextCursor != "" {
+		t.Fatalf("fetch-all = %d spaces with next_cursor %q, want 3 and none", len(all.Results), all.NextCursor)
+	}
+}
+
+func TestGetSpaceV2(t *testing.T) {
+	client, _ := newV2Client(t)
+
+	space, err := client.GetSpace("ENG")
+	if err != nil {
+		t.Fatalf("GetSpace: %v", err)
+	}
+	want := &models.ConfluenceSpace{ID: 98305, Key: "ENG", Name: "Engineering", Type: "global",
+		Description: "Engineering handbook and runbooks"}
+	if !reflect.DeepEqual(space, want) {
+		t.Fatalf("GetSpace = %+v, want %+v", space, want)
+	}
+
+	// v2 answers an unknown key with an empty list; callers get a not found error
+	_, err = client.GetSpace("NOPE")
+	if atlassian.ErrorCode(err) != models.ErrCodeNotFound {
+		t.Fatalf("GetSpace of a missing space = %v, want a not found error", err)
+	}
+}
+
+func TestDataCenterUsesV1(t *testing.T) {
+	client, fake := newV2Client(t)
+	client = NewClient(atlassian.Credentials{Site: client.Site(), Token: "pat", Deployment: models.DeploymentDataCenter})
+
+	// Data Center has no v2 API; the fake only serves v2, so the v1 request fails
+	client.GetPage("393217")
+	if paths := fake.paths(); len(paths) != 1 || paths[0] != "/wiki/rest/api/content/393217" {
+		t.Fatalf("requests = %v, want the v1 content endpoint", paths)
+	}
+}
@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/cmd/confluence-service/api"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// maxSuffixAttempts bounds the search for a free title in suffix mode
+const maxSuffixAttempts = 50
+
+// Limits on the number of pages one copy may read. Every page body is held in memory
+// until the copy finishes, and a larger tree would outlast the copy_page deadline.
+const (
+	defaultMaxCopyPages = 200
+	maxCopyPages        = 1000
+)
+
+// errTooManyPages stops a copy whose source tree has more pages than allowed
+var errTooManyPages = errors.New("source tree has too many pages")
+
+// pageCopier copies a page, or a page tree, between two clients
+type pageCopier struct {
+	src         *api.Client
+	dst         *api.Client
+	spaceKey    string
+	maxDepth    int // Deepest level of descendants copied; -1 for no limit
+	maxPages    int // Most pages the source tree may have
+	onConflict  string
+	attachments bool            // Transfer attachments with each page
+	sameSite    bool            // Both workspaces are on the same Confluence site
+	sourceIDs   map[string]bool // Pages of the fetched source tree
+	rewriter    *linkRewriter
+	written     []writtenPage // Pages whose body the copy wrote, for the rewrite pass
+	result      *models.CopyResult
+}
+
+// writtenPage is a destination page whose body came from the copy
+type writtenPage struct {
+	sourceID    string
+	sourceSpace string
+	page        *models.ConfluencePage
+	body        string
+	index       int // Position in result.Pages
+}
+
+func (s *Service) handleCopyPage(req models.ConfluenceRequest) map[string]interface{} {
+	srcWorkspace, ok := req.Params["src_workspace"].(string)
+	if !ok {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing src_workspace", req.RequestID)
+	}
+
+	dstWorkspace, ok := req.Params["dst_workspace"].(string)
+	if !ok {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing dst_workspace", req.RequestID)
+	}
+
+	srcPageID, ok := req.Params["src_page_id"].(string)
+	if !ok {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing src_page_id", req.RequestID)
+	}
+
+	dstSpaceKey, ok := req.Params["dst_space_key"].(string)
+	if !ok {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing dst_space_key", req.RequestID)
+	}
+
+	var dstParentID *string
+	if pid, ok := req.Params["dst_parent_id"].(string); ok && pid != "" {
+		dstParentID = &pid
+	}
+
+	// Only the root page unless recursive; then every level unless max_depth is set
+	maxDepth := 0
+	if recursive, _ := req.Params["recursive"].(bool); recursive {
+		maxDepth = -1
+		if d, ok := req.Params["max_depth"].(float64); ok && d >= 0 {
+			maxDepth = int(d)
+		}
+	}
+
+	maxPages := defaultMaxCopyPages
+	if v, ok := req.Params["max_pages"].(float64); ok {
+		if v < 1 || v > maxCopyPages {
+			return models.ErrorResponse(models.ErrCodeInvalidRequest,
+				fmt.Sprintf("max_pages must be between 1 and %d", maxCopyPages), req.RequestID)
+		}
+		maxPages = int(v)
+	}
+
+	attachments := true
+	if v, ok := req.Params["attachments"].(bool); ok {
+		attachments = v
+	}
+
+	macroMode, _ := req.Params["jira_macros"].(string)
+	switch macroMode {
+	case "":
+		macroMode = models.CopyMacrosLink
+	case models.CopyMacrosLink, models.CopyMacrosKeep:
+	default:
+		return models.ErrorResponse(models.ErrCodeInvalidRequest,
+			fmt.Sprintf("invalid jira_macros %q (expected %s or %s)", macroMode, models.CopyMacrosLink, models.CopyMacrosKeep),
+			req.RequestID)
+	}
+
+	mentionMode, _ := req.Params["mentions"].(string)
+	switch mentionMode {
+	case "", models.CopyMentionsText, models.CopyMentionsKeep:
+	default:
+		return models.ErrorResponse(models.ErrCodeInvalidRequest,
+			fmt.Sprintf("invalid mentions %q (expected %s or %s)", mentionMode, models.CopyMentionsText, models.CopyMentionsKeep),
+			req.RequestID)
+	}
+
+	onConflict, _ := req.Params["on_conflict"].(string)
+	switch onConflict {
+	case "":
+		onConflict = models.CopyConflictFail
+	case models.CopyConflictFail, models.CopyConflictSkip, models.CopyConflictSuffix, models.CopyConflictOverwrite:
+	default:
+		return models.ErrorResponse(models.ErrCodeInvalidRequest,
+			fmt.Sprintf("invalid on_conflict %q (expected %s, %s, %s or %s)", onConflict,
+				models.CopyConflictFail, models.CopyConflictSkip, models.CopyConflictSuffix, models.CopyConflictOverwrite),
+			req.RequestID)
+	}
+
+	// Get credentials for both workspaces
+	srcCreds, err := s.credStore.GetCredentials(req.UserID, srcWorkspace)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeAuthFailed,
+			fmt.Sprintf("source workspace not found: %s", srcWorkspace), req.RequestID)
+	}
+
+	dstCreds, err := s.credStore.GetCredentials(req.UserID, dstWorkspace)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeAuthFailed,
+			fmt.Sprintf("destination workspace not found: %s", dstWorkspace), req.RequestID)
+	}
+
+	if !srcCreds.HasProduct(models.ProductConfluence) {
+		return models.ErrorResponse(models.ErrCodeProductDisabled,
+			fmt.Sprintf("Confluence is not enabled for workspace %s", srcWorkspace), req.RequestID)
+	}
+	if !dstCreds.HasProduct(models.ProductConfluence) {
+		return models.ErrorResponse(models.ErrCodeProductDisabled,
+			fmt.Sprintf("Confluence is not enabled for workspace %s", dstWorkspace), req.RequestID)
+	}
+
+	// Account IDs mean nothing to another Data Center install, so mentions are converted
+	// by default when either side is one; Cloud account IDs are shared across sites
+	if mentionMode == "" {
+		mentionMode = models.CopyMentionsKeep
+		if srcCreds.DeploymentType() == models.DeploymentDataCenter || dstCreds.DeploymentType() == models.DeploymentDataCenter {
+			mentionMode = models.CopyMentionsText
+		}
+	}
+
+	srcJira := ""
+	if srcCreds.HasProduct(models.ProductJira) {
+		srcJira = srcCreds.BrowseURL(models.ProductJira)
+	}
+
+	// Get pooled clients for both workspaces
+	src := s.clients.Get(req.UserID, srcWorkspace, s.apiCredentials(req.UserID, srcWorkspace, srcCreds))
+	copier := &pageCopier{
+		src:         src,
+		dst:         s.clients.Get(req.UserID, dstWorkspace, s.apiCredentials(req.UserID, dstWorkspace, dstCreds)),
+		spaceKey:    dstSpaceKey,
+		maxDepth:    maxDepth,
+		maxPages:    maxPages,
+		onConflict:  onConflict,
+		attachments: attachments,
+		sameSite:    srcCreds.BrowseURL(models.ProductConfluence) == dstCreds.BrowseURL(models.ProductConfluence),
+		sourceIDs:   make(map[string]bool),
+		rewriter: &linkRewriter{
+			srcSite:     srcCreds.BrowseURL(models.ProductConfluence),
+			srcJira:     srcJira,
+			dstSpace:    dstSpaceKey,
+			titles:      make(map[string]string),
+			macroMode:   macroMode,
+			mentionMode: mentionMode,
+			userName: func(accountID, userKey string) string {
+				user, err := src.GetUser(accountID, userKey)
+				if err != nil {
+					return ""
+				}
+				return user.DisplayName
+			},
+			names: make(map[string]string),
+		},
+		result: &models.CopyResult{
+			PageMap: make(map[string]string),
+		},
+	}
+
+	// A copy placed below its own source would be part of the tree it copies
+	if dstParentID != nil && copier.sameSite {
+		inside, err := copier.insideSource(srcPageID, *dstParentID)
+		if err != nil {
+			return atlassian.ErrorResponse(err, req.RequestID)
+		}
+		if inside {
+			return models.ErrorResponse(models.ErrCodeInvalidRequest,
+				fmt.Sprintf("dst_parent_id %s is inside the page tree being copied", *dstParentID), req.RequestID)
+		}
+	}
+
+	tree, err := copier.fetchTree(srcPageID, 0)
+	if errors.Is(err, errTooManyPages) {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest,
+			fmt.Sprintf("page %s has more than %d pages below it, so nothing was copied; set max_depth, copy a subtree or raise max_pages (up to %d)",
+				srcPageID, maxPages, maxCopyPages), req.RequestID)
+	}
+	if err != nil {
+		return atlassian.ErrorResponse(err, req.RequestID)
+	}
+
+	root, err := copier.copyTree(tree, dstParentID)
+	if err != nil {
+		if len(copier.result.Pages) == 0 {
+			return atlassian.ErrorResponse(err, req.RequestID)
+		}
+		// Report what was copied before the failure so the caller can clean up or resume
+		return models.ErrorResponseWithDetails(atlassian.ErrorCode(err),
+			fmt.Sprintf("copy stopped after %d page(s): %v", len(copier.result.Pages), err),
+			copier.result, req.RequestID)
+	}
+	copier.result.Root = root
+
+	if err := copier.rewriteReferences(); err != nil {
+		return models.ErrorResponseWithDetails(atlassian.ErrorCode(err),
+			fmt.Sprintf("pages were copied but rewriting their links failed: %v", err),
+			copier.result, req.RequestID)
+	}
+
+	return models.SuccessResponse(copier.result, req.RequestID)
+}
+
+// sourcePage is a page of the source tree with the descendants that will be copied
+type sourcePage struct {
+	page     *models.ConfluencePage
+	depth    int
+	children []*sourcePage
+}
+
+// fetchTree reads a page and, within the depth limit, its descendants. The whole tree is
+// read before anything is written, so copies made inside the source tree are never
+// walked as part of it, and a tree over the page limit is refused without a partial copy.
+func (c *pageCopier) fetchTree(srcPageID string, depth int) (*sourcePage, error) {
+	if len(c.sourceIDs) >= c.maxPages {
+		return nil, errTooManyPages
+	}
+	page, err := c.src.GetPage(srcPageID)
+	if err != nil {
+		return nil, err
+	}
+	c.sourceIDs[page.ID] = true
+	node := &sourcePage{page: page, depth: depth}
+
+	if c.maxDepth >= 0 && depth >= c.maxDepth {
+		// Note pages left out by max_depth; single-page copies have nothing to note
+		if c.maxDepth > 0 {
+			if children, err := c.src.GetChildren(srcPageID, atlassian.PageRequest{Limit: 1}); err == nil && len(children.Results) > 0 {
+				c.result.Truncated = true
+			}
+		}
+		return node, nil
+	}
+
+	// More children than the page limit make the tree too large in any case
+	children, err := c.src.GetChildren(srcPageID, atlassian.PageRequest{Limit: c.maxPages, All: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children.Results {
+		if c.sourceIDs[child.ID] {
+			continue
+		}
+		childNode, err := c.fetchTree(child.ID, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.children = append(node.children, childNode)
+	}
+
+	return node, nil
+}
+
+// copyTree copies a fetched page and its fetched descendants in order. It returns the
+// destination page.
+func (c *pageCopier) copyTree(node *sourcePage, dstParentID *string) (*models.ConfluencePage, error) {
+	page := node.page
+	dstPage, outcome, err := c.copyPage(page, dstParentID)
+	if err != nil {
+		return nil, err
+	}
+	copied := models.CopiedPage{
+		SourceID:      page.ID,
+		DestinationID: dstPage.ID,
+		Title:         dstPage.Title,
+		Depth:         node.depth,
+		Outcome:       outcome,
+	}
+	if c.attachments && outcome != models.CopySkipped {
+		c.transferAttachments(page.ID, dstPage.ID, &copied)
+	}
+	c.result.PageMap[page.ID] = dstPage.ID
+	c.rewriter.titles[pageKey(page.Space.Key, page.Title)] = dstPage.Title
+	if outcome != models.CopySkipped {
+		c.written = append(c.written, writtenPage{
+			sourceID:    page.ID,
+			sourceSpace: page.Space.Key,
+			page:        dstPage,
+			body:        unpinAttachmentVersions(page.Body.Storage.Value),
+			index:       len(c.result.Pages),
+		})
+	}
+	c.result.Pages = append(c.result.Pages, copied)
+
+	for _, child := range node.children {
+		if _, err := c.copyTree(child, &dstPage.ID); err != nil {
+			return dstPage, err
+		}
+	}
+
+	return dstPage, nil
+}
+
+// insideSource reports whether a destination parent is the source page or one of its
+// descendants; both workspaces must be on the same site
+func (c *pageCopier) insideSource(srcPageID, dstParentID string) (bool, error) {
+	if dstParentID == srcPageID {
+		return true, nil
+	}
+	ancestors, err := c.dst.GetAncestorIDs(dstParentID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ancestors {
+		if id == srcPageID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rewriteReferences points the references in copied pages at the destination, once every
+// page is copied and the titles of the copies are known. Pages whose references need no
+// change are left at the version the copy created.
+func (c *pageCopier) rewriteReferences() error {
+	for _, w := range c.written {
+		body, rewritten, unresolved := c.rewriter.rewrite(w.body, w.sourceSpace, w.sourceID)
+		c.result.Unresolved = append(c.result.Unresolved, unresolved...)
+		if body == w.body {
+			continue
+		}
+
+		version := w.page.Version.Number
+		if version == 0 {
+			version = 1
+		}
+		if _, err := c.dst.UpdatePage(w.page.ID, w.page.Title, body, version+1,
+			"Links rewritten for the destination site", nil); err != nil {
+			return err
+		}
+		c.result.Pages[w.index].LinksRewritten = rewritten
+	}
+
+	return nil
+}
+
+// copyPage creates one page in the destination, resolving a title that is already
+// taken in the destination space according to the conflict strategy. An overwritten
+// page is moved under parentID; without one it stays where it is.
+func (c *pageCopier) copyPage(page *models.ConfluencePage, parentID *string) (*models.ConfluencePage, string, error) {
+	body := unpinAttachmentVersions(page.Body.Storage.Value)
+
+	if c.onConflict == models.CopyConflictFail {
+		created, err := c.dst.CreatePage(c.spaceKey, page.Title, body, parentID)
+		return created, models.CopyCreated, err
+	}
+
+	existing, err := c.dst.FindPageByTitle(c.spaceKey, page.Title)
+	if err != nil {
+		return nil, "", err
+	}
+	if existing == nil {
+		created, err := c.dst.CreatePage(c.spaceKey, page.Title, body, parentID)
+		return created, models.CopyCreated, err
+	}
+
+	// Skipping to or overwriting a page of the source tree would turn the copy into a
+	// move of the original, and reusing a page this copy made would merge two copies;
+	// both get a suffixed title instead
+	if !c.ownPage(existing.ID) {
+		switch c.onConflict {
+		case models.CopyConflictSkip:
+			return existing, models.CopySkipped, nil
+		case models.CopyConflictOverwrite:
+			updated, err := c.dst.UpdatePage(existing.ID, existing.Title, body, existing.Version.Number+1,
+				fmt.Sprintf("Overwritten by a copy of page %s", page.ID), parentID)
+			return updated, models.CopyOverwritten, err
+		}
+	}
+
+	for n := 1; n <= maxSuffixAttempts; n++ {
+		title := fmt.Sprintf("%s (copy)", page.Title)
+		if n > 1 {
+			title = fmt.Sprintf("%s (copy %d)", page.Title, n)
+		}
+		taken, err := c.dst.FindPageByTitle(c.spaceKey, title)
+		if err != nil {
+			return nil, "", err
+		}
+		if taken == nil {
+			created, err := c.dst.CreatePage(c.spaceKey, title, body, parentID)
+			return created, models.CopyCreated, err
+		}
+	}
+	return nil, "", fmt.Errorf("no free title for a copy of '%s' after %d attempts", page.Title, maxSuffixAttempts)
+}
+
+// ownPage reports whether a destination page belongs to the copy itself: a page of the
+// source tree, when both workspaces are on the same site, or a page the copy has used
+func (c *pageCopier) ownPage(pageID string) bool {
+	if c.sameSite && c.sourceIDs[pageID] {
+		return true
+	}
+	for _, dstID := range c.result.PageMap {
+		if dstID == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// transferAttachments copies the attachments of a source page to its copy, uploading
+// new versions of attachments the destination already has. Failures are recorded on
+// the page rather than stopping the copy.
+func (c *pageCopier) transferAttachments(srcPageID, dstPageID string, copied *models.CopiedPage) {
+	attachments, err := c.src.ListAttachments(srcPageID)
+	if err != nil {
+		copied.AttachmentErrors = append(copied.AttachmentErrors, err.Error())
+		return
+	}
+	if len(attachments) == 0 {
+		return
+	}
+
+	existing := make(map[string]string)
+	if copied.Outcome == models.CopyOverwritten {
+		current, err := c.dst.ListAttachments(dstPageID)
+		if err != nil {
+			copied.AttachmentErrors = append(copied.AttachmentErrors, err.Error())
+			return
+		}
+		for _, a := range current {
+			existing[a.Title] = a.ID
+		}
+	}
+
+	for _, attachment := range attachments {
+		if err := c.transferAttachment(attachment, dstPageID, existing[attachment.Title]); err != nil {
+			copied.AttachmentErrors = append(copied.AttachmentErrors, err.Error())
+			continue
+		}
+		copied.Attachments++
+	}
+}
+
+// transferAttachment streams one attachment from the source to the destination page
+func (c *pageCopier) transferAttachment(attachment models.ConfluenceAttachment, dstPageID, existingID string) error {
+	content, err := c.src.DownloadAttachment(attachment)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	_, err = c.dst.UploadAttachment(dstPageID, existingID, attachment.Title, attachment.Extensions.MediaType, content,
+		attachment.Extensions.FileSize)
+	return err
+}
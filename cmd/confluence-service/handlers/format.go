@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/markdown"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// bodyFormat returns the body format requested by the format param, storage by default
+func bodyFormat(req models.ConfluenceRequest) (string, error) {
+	format, _ := req.Params["format"].(string)
+	switch format {
+	case "":
+		return models.BodyFormatStorage, nil
+	case models.BodyFormatStorage, models.BodyFormatMarkdown:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid format %q (expected %s or %s)", format,
+			models.BodyFormatStorage, models.BodyFormatMarkdown)
+	}
+}
+
+// toStorage converts a body supplied in format to storage format
+func toStorage(body, format string) string {
+	if format == models.BodyFormatMarkdown {
+		return markdown.ToStorage(body)
+	}
+	return body
+}
+
+// formatPage returns the page with its body in format. A body that cannot be
+// converted is left in storage format.
+func formatPage(page *models.ConfluencePage, format string) *models.ConfluencePage {
+	if format != models.BodyFormatMarkdown || page.Body.Storage.Value == "" {
+		return page
+	}
+
+	md, err := markdown.FromStorage(page.Body.Storage.Value)
+	if err != nil {
+		return page
+	}
+
+	converted := *page
+	converted.Body = models.PageBody{Markdown: md}
+	return &converted
+}
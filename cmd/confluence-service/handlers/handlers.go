@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	"github.com/providentiaww/trilix-atlassian-mcp/cmd/confluence-service/api"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/oauth"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -14,15 +16,29 @@ import (
 // Service handles Confluence service requests
 type Service struct {
 	credStore storage.CredentialStoreInterface
+	clients   *api.ClientPool
+	oauth     *oauth.Config // Optional; needed to refresh OAuth 2.0 tokens
 }
 
 // NewService creates a new Confluence service
-func NewService(credStore storage.CredentialStoreInterface) *Service {
+func NewService(credStore storage.CredentialStoreInterface, clients *api.ClientPool, oauthConfig *oauth.Config) *Service {
 	return &Service{
 		credStore: credStore,
+		clients:   clients,
+		oauth:     oauthConfig,
 	}
 }
 
+// apiCredentials converts stored credentials into Confluence client credentials
+func (s *Service) apiCredentials(userID, workspaceID string, creds *models.WorkspaceCredentials) atlassian.Credentials {
+	var tokenSource atlassian.TokenSource
+	if creds.AuthKind() == models.AuthTypeOAuth2 && creds.OAuth != nil {
+		tokenSource = oauth.NewRefreshingTokenSource(s.oauth, s.credStore, userID, workspaceID, *creds.OAuth)
+	}
+
+	return atlassian.CredentialsFor(creds, models.ProductConfluence, tokenSource)
+}
+
 // HandleRequest processes incoming RabbitMQ messages
 func (s *Service) HandleRequest(d amqp.Delivery) []byte {
 	var req models.ConfluenceRequest
@@ -32,45 +48,53 @@ func (s *Service) HandleRequest(d amqp.Delivery) []byte {
 		return responseBytes
 	}
 
+	// Validation checks a product even before it is enabled for the workspace
+	if req.Action == "validate_credentials" {
+		responseBytes, _ := json.Marshal(s.handleValidateCredentials(req))
+		return responseBytes
+	}
+
 	// Get credentials for the workspace
 	creds, err := s.credStore.GetCredentials(req.UserID, req.WorkspaceID)
 	if err != nil {
+		s.clients.Invalidate(req.UserID, req.WorkspaceID)
 		response := models.ErrorResponse(models.ErrCodeAuthFailed,
 			fmt.Sprintf("workspace not found: %s", req.WorkspaceID), req.RequestID)
 		responseBytes, _ := json.Marshal(response)
 		return responseBytes
 	}
 
-	// Ensure Site URL includes /wiki for Confluence API
-	site := creds.Site
-	if site != "" && !strings.HasSuffix(site, "/wiki") {
-		// Remove trailing slash if present, then add /wiki
-		site = strings.TrimSuffix(site, "/")
-		site += "/wiki"
+	if !creds.HasProduct(models.ProductConfluence) {
+		response := models.ErrorResponse(models.ErrCodeProductDisabled,
+			fmt.Sprintf("Confluence is not enabled for workspace %s", req.WorkspaceID), req.RequestID)
+		responseBytes, _ := json.Marshal(response)
+		return responseBytes
 	}
 
-	// Create API client
-	client := api.NewClient(api.WorkspaceCredentials{
-		Site:  site,
-		Email: creds.Email,
-		Token: creds.Token,
-	})
+	// Get a pooled API client (rebuilt automatically if the credentials changed)
+	client := s.clients.Get(req.UserID, req.WorkspaceID, s.apiCredentials(req.UserID, req.WorkspaceID, creds))
 
 	// Route to appropriate handler
 	var response map[string]interface{}
 	switch req.Action {
 	case "get_page":
 		response = s.handleGetPage(client, req)
+	case "get_children":
+		response = s.handleGetChildren(client, req)
 	case "create_page":
 		response = s.handleCreatePage(client, req)
+	case "update_page":
+		response = s.handleUpdatePage(client, req)
 	case "search":
-		response = s.handleSearch(client, req)
+		response = s.handleSearch(client, creds, req)
 	case "list_spaces":
 		response = s.handleListSpaces(client, req)
 	case "get_space":
 		response = s.handleGetSpace(client, req)
 	case "copy_page":
 		response = s.handleCopyPage(req)
+	case "health_check":
+		response = s.handleHealthCheck(client, creds, req)
 	default:
 		response = models.ErrorResponse(models.ErrCodeInvalidRequest,
 			fmt.Sprintf("unknown action: %s", req.Action), req.RequestID)
@@ -80,18 +104,61 @@ func (s *Service) HandleRequest(d amqp.Delivery) []byte {
 	return responseBytes
 }
 
+// handleValidateCredentials checks a stored workspace's credentials against /user/current.
+// Unsaved API tokens are validated by the MCP server itself and never reach the service.
+func (s *Service) handleValidateCredentials(req models.ConfluenceRequest) map[string]interface{} {
+	creds, err := s.credStore.GetCredentials(req.UserID, req.WorkspaceID)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeAuthFailed,
+			fmt.Sprintf("workspace not found: %s", req.WorkspaceID), req.RequestID)
+	}
+
+	apiCreds := s.apiCredentials(req.UserID, req.WorkspaceID, creds)
+	user, err := s.clients.NewClient(apiCreds).GetCurrentUser()
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeAuthFailed, err.Error(), req.RequestID)
+	}
+
+	accountID := user.AccountID
+	if accountID == "" {
+		accountID = user.Username
+	}
+
+	return models.SuccessResponse(models.CredentialCheck{
+		Product:     models.ProductConfluence,
+		BaseURL:     apiCreds.Site,
+		AccountID:   accountID,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+	}, req.RequestID)
+}
+
+// handleHealthCheck probes the workspace with live requests and reports its health
+func (s *Service) handleHealthCheck(client *api.Client, creds *models.WorkspaceCredentials, req models.ConfluenceRequest) map[string]interface{} {
+	health := client.Probe()
+	if creds.OAuth != nil {
+		health.Scopes = strings.Fields(creds.OAuth.Scope)
+	}
+	return models.SuccessResponse(health, req.RequestID)
+}
+
 func (s *Service) handleGetPage(client *api.Client, req models.ConfluenceRequest) map[string]interface{} {
 	pageID, ok := req.Params["page_id"].(string)
 	if !ok {
 		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing page_id", req.RequestID)
 	}
 
+	format, err := bodyFormat(req)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+
 	page, err := client.GetPage(pageID)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
 
-	return models.SuccessResponse(page, req.RequestID)
+	return models.SuccessResponse(formatPage(page, format), req.RequestID)
 }
 
 func (s *Service) handleCreatePage(client *api.Client, req models.ConfluenceRequest) map[string]interface{} {
@@ -110,50 +177,55 @@ func (s *Service) handleCreatePage(client *api.Client, req models.ConfluenceRequ
 		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing body", req.RequestID)
 	}
 
+	format, err := bodyFormat(req)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+
 	var parentID *string
 	if pid, ok := req.Params["parent_id"].(string); ok && pid != "" {
 		parentID = &pid
 	}
 
-	page, err := client.CreatePage(spaceKey, title, body, parentID)
+	page, err := client.CreatePage(spaceKey, title, toStorage(body, format), parentID)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
 
-	return models.SuccessResponse(page, req.RequestID)
+	return models.SuccessResponse(formatPage(page, format), req.RequestID)
 }
 
-func (s *Service) handleSearch(client *api.Client, req models.ConfluenceRequest) map[string]interface{} {
-	query, ok := req.Params["query"].(string)
-	if !ok {
-		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing query", req.RequestID)
-	}
-
-	limit := 10
-	if l, ok := req.Params["limit"].(float64); ok {
-		limit = int(l)
+func (s *Service) handleListSpaces(client *api.Client, req models.ConfluenceRequest) map[string]interface{} {
+	page, err := atlassian.PageRequestFromParams(req.Params, 50)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
 	}
 
-	results, err := client.SearchPages(query, limit)
+	spaces, err := client.ListSpaces(page)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
 
-	return models.SuccessResponse(results, req.RequestID)
+	return models.SuccessResponse(spaces, req.RequestID)
 }
 
-func (s *Service) handleListSpaces(client *api.Client, req models.ConfluenceRequest) map[string]interface{} {
-	limit := 50
-	if l, ok := req.Params["limit"].(float64); ok {
-		limit = int(l)
+func (s *Service) handleGetChildren(client *api.Client, req models.ConfluenceRequest) map[string]interface{} {
+	pageID, ok := req.Params["page_id"].(string)
+	if !ok {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing page_id", req.RequestID)
 	}
 
-	spaces, err := client.ListSpaces(limit)
+	page, err := atlassian.PageRequestFromParams(req.Params, 50)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
 	}
 
-	return models.SuccessResponse(spaces, req.RequestID)
+	children, err := client.GetChildren(pageID, page)
+	if err != nil {
+		return atlassian.ErrorResponse(err, req.RequestID)
+	}
+
+	return models.SuccessResponse(children, req.RequestID)
 }
 
 func (s *Service) handleGetSpace(client *api.Client, req models.ConfluenceRequest) map[string]interface{} {
@@ -164,76 +236,8 @@ func (s *Service) handleGetSpace(client *api.Client, req models.ConfluenceReques
 
 	space, err := client.GetSpace(spaceKey)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
 
 	return models.SuccessResponse(space, req.RequestID)
 }
-
-func (s *Service) handleCopyPage(req models.ConfluenceRequest) map[string]interface{} {
-	srcWorkspace, ok := req.Params["src_workspace"].(string)
-	if !ok {
-		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing src_workspace", req.RequestID)
-	}
-
-	dstWorkspace, ok := req.Params["dst_workspace"].(string)
-	if !ok {
-		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing dst_workspace", req.RequestID)
-	}
-
-	srcPageID, ok := req.Params["src_page_id"].(string)
-	if !ok {
-		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing src_page_id", req.RequestID)
-	}
-
-	dstSpaceKey, ok := req.Params["dst_space_key"].(string)
-	if !ok {
-		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing dst_space_key", req.RequestID)
-	}
-
-	var dstParentID *string
-	if pid, ok := req.Params["dst_parent_id"].(string); ok && pid != "" {
-		dstParentID = &pid
-	}
-
-	// Get credentials for both workspaces
-	srcCreds, err := s.credStore.GetCredentials(req.UserID, srcWorkspace)
-	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAuthFailed,
-			fmt.Sprintf("source workspace not found: %s", srcWorkspace), req.RequestID)
-	}
-
-	dstCreds, err := s.credStore.GetCredentials(req.UserID, dstWorkspace)
-	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAuthFailed,
-			fmt.Sprintf("destination workspace not found: %s", dstWorkspace), req.RequestID)
-	}
-
-	// Create clients for both workspaces
-	srcClient := api.NewClient(api.WorkspaceCredentials{
-		Site:  srcCreds.Site,
-		Email: srcCreds.Email,
-		Token: srcCreds.Token,
-	})
-
-	dstClient := api.NewClient(api.WorkspaceCredentials{
-		Site:  dstCreds.Site,
-		Email: dstCreds.Email,
-		Token: dstCreds.Token,
-	})
-
-	// Read from source
-	page, err := srcClient.GetPage(srcPageID)
-	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
-	}
-
-	// Create in destination
-	newPage, err := dstClient.CreatePage(dstSpaceKey, page.Title, page.Body.Storage.Value, dstParentID)
-	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
-	}
-
-	return models.SuccessResponse(newPage, req.RequestID)
-}
-
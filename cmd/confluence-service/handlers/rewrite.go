@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// attachmentVersionPattern matches the version pin of an attachment reference
+var attachmentVersionPattern = regexp.MustCompile(`(<ri:attachment\b[^>]*?)\s+ri:version-at-save="[^"]*"`)
+
+// unpinAttachmentVersions drops ri:version-at-save from attachment references.
+// Copied attachments restart at version 1 in the destination, so a pin to a
+// later version would render as a missing image.
+func unpinAttachmentVersions(storage string) string {
+	return attachmentVersionPattern.ReplaceAllString(storage, "$1")
+}
+
+// Storage format elements that refer to other content. ac:link and the Jira macro are
+// matched whole since they never nest; a self-closing tag ends at its "/>" rather than
+// at the next closing tag. ri:page also appears outside links, e.g., in attachment
+// references and include macros.
+var (
+	referencePattern = regexp.MustCompile(`(?s)<ac:link\b[^>]*?(?:/>|>.*?</ac:link>)|` +
+		`<ac:structured-macro\b[^>]*\bac:name="jira"[^>]*?(?:/>|>.*?</ac:structured-macro>)|<ri:page\b[^>]*/>`)
+	riPagePattern     = regexp.MustCompile(`<ri:page\b[^>]*/>`)
+	riUserPattern     = regexp.MustCompile(`<ri:user\b[^>]*/>`)
+	linkBodyPattern   = regexp.MustCompile(`(?s)<ac:(?:plain-text-)?link-body>(?:<!\[CDATA\[)?(.*?)(?:\]\]>)?</ac:(?:plain-text-)?link-body>`)
+	macroParamPattern = regexp.MustCompile(`(?s)<ac:parameter\b[^>]*\bac:name="([^"]*)"[^>]*>(.*?)</ac:parameter>`)
+)
+
+// attribute returns the unescaped value of an attribute in a tag, or ""
+func attribute(tag, name string) string {
+	m := attributePatterns[name].FindStringSubmatch(tag)
+	if m == nil {
+		return ""
+	}
+	return html.UnescapeString(m[2])
+}
+
+// setAttribute replaces the value of an attribute that is present in a tag
+func setAttribute(tag, name, value string) string {
+	return attributePatterns[name].ReplaceAllLiteralString(tag, " "+name+`="`+html.EscapeString(value)+`"`)
+}
+
+// attributePatterns match the storage format attributes that references are resolved by
+var attributePatterns = map[string]*regexp.Regexp{
+	"ri:content-title": regexp.MustCompile(`(\s)ri:content-title="([^"]*)"`),
+	"ri:space-key":     regexp.MustCompile(`(\s)ri:space-key="([^"]*)"`),
+	"ri:account-id":    regexp.MustCompile(`(\s)ri:account-id="([^"]*)"`),
+	"ri:userkey":       regexp.MustCompile(`(\s)ri:userkey="([^"]*)"`),
+}
+
+// linkRewriter points references in copied pages at the destination. Links to pages
+// copied in the same operation are rewritten; links to other pages become plain links
+// to the source site; Jira macros and user mentions are converted or kept per mode.
+type linkRewriter struct {
+	srcSite     string            // Confluence browse URL of the source
+	srcJira     string            // Jira browse URL of the source; empty if unknown
+	dstSpace    string            // Destination space key
+	titles      map[string]string // pageKey(source space, source title) to destination title
+	macroMode   string
+	mentionMode string
+	userName    func(accountID, userKey string) string // Display name of a source user, "" if unknown
+	names       map[string]string                      // userName results
+}
+
+// pageKey identifies a page by space and title, as storage format references do
+func pageKey(spaceKey, title string) string {
+	return spaceKey + "\x00" + title
+}
+
+// rewrite returns the storage with its references rewritten, how many references now
+// point at copied pages, and the references that could not be resolved.
+// pageSpace is the source space of the page, which references without a space key mean.
+func (r *linkRewriter) rewrite(storage, pageSpace, sourcePageID string) (string, int, []models.CopyReference) {
+	rewritten := 0
+	var unresolved []models.CopyReference
+	report := func(kind, target, action string) {
+		unresolved = append(unresolved, models.CopyReference{
+			SourcePageID: sourcePageID,
+			Kind:         kind,
+			Target:       target,
+			Action:       action,
+		})
+	}
+
+	out := referencePattern.ReplaceAllStringFunc(storage, func(element string) string {
+		switch {
+		case strings.HasPrefix(element, "<ac:structured-macro"):
+			return r.rewriteJiraMacro(element, report)
+
+		case strings.HasPrefix(element, "<ri:page"):
+			tag, ok := r.rewritePageRef(element, pageSpace)
+			if ok {
+				rewritten++
+			} else {
+				report(models.ReferencePageLink, attribute(element, "ri:content-title"), models.ReferenceKept)
+			}
+			return tag
+
+		case riUserPattern.MatchString(element):
+			return r.rewriteMention(element, report)
+		}
+
+		// A link; only page links need attention
+		pageTag := riPagePattern.FindString(element)
+		if pageTag == "" {
+			return element
+		}
+		if tag, ok := r.rewritePageRef(pageTag, pageSpace); ok {
+			rewritten++
+			return strings.Replace(element, pageTag, tag, 1)
+		}
+
+		title := attribute(pageTag, "ri:content-title")
+		space := attribute(pageTag, "ri:space-key")
+		if space == "" {
+			space = pageSpace
+		}
+		if r.srcSite == "" || title == "" {
+			report(models.ReferencePageLink, title, models.ReferenceKept)
+			return element
+		}
+
+		report(models.ReferencePageLink, title, models.ReferenceLinkedToSource)
+		href := fmt.Sprintf("%s/display/%s/%s", r.srcSite, url.PathEscape(space), url.PathEscape(title))
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), linkText(element, html.EscapeString(title)))
+	})
+
+	return out, rewritten, unresolved
+}
+
+// rewritePageRef points an ri:page tag at the copy of the page it names, if it was copied
+func (r *linkRewriter) rewritePageRef(tag, pageSpace string) (string, bool) {
+	title := attribute(tag, "ri:content-title")
+	space := attribute(tag, "ri:space-key")
+	if space == "" {
+		space = pageSpace
+	}
+
+	dstTitle, ok := r.titles[pageKey(space, title)]
+	if !ok {
+		return tag, false
+	}
+
+	tag = setAttribute(tag, "ri:content-title", dstTitle)
+	if attribute(tag, "ri:space-key") != "" {
+		tag = setAttribute(tag, "ri:space-key", r.dstSpace)
+	}
+	return tag, true
+}
+
+// rewriteJiraMacro converts a Jira issue macro into a link to the source Jira, since its
+// server ID names an application link of the source site
+func (r *linkRewriter) rewriteJiraMacro(macro string, report func(kind, target, action string)) string {
+	params := make(map[string]string)
+	for _, m := range macroParamPattern.FindAllStringSubmatch(macro, -1) {
+		params[m[1]] = html.UnescapeString(m[2])
+	}
+
+	target := params["key"]
+	href := ""
+	if target != "" {
+		href = fmt.Sprintf("%s/browse/%s", r.srcJira, url.PathEscape(target))
+	} else if jql := params["jqlQuery"]; jql != "" {
+		target = jql
+		href = fmt.Sprintf("%s/issues/?jql=%s", r.srcJira, url.QueryEscape(jql))
+	}
+
+	if r.macroMode != models.CopyMacrosLink || r.srcJira == "" || target == "" {
+		report(models.ReferenceJiraMacro, target, models.ReferenceKept)
+		return macro
+	}
+
+	report(models.ReferenceJiraMacro, target, models.ReferenceLinkedToSource)
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), html.EscapeString(target))
+}
+
+// rewriteMention keeps a user mention or replaces it with the user's name
+func (r *linkRewriter) rewriteMention(link string, report func(kind, target, action string)) string {
+	userTag := riUserPattern.FindString(link)
+	accountID := attribute(userTag, "ri:account-id")
+	userKey := attribute(userTag, "ri:userkey")
+	target := accountID
+	if target == "" {
+		target = userKey
+	}
+
+	if r.mentionMode != models.CopyMentionsText {
+		report(models.ReferenceUserMention, target, models.ReferenceKept)
+		return link
+	}
+
+	name, ok := r.names[target]
+	if !ok {
+		name = r.userName(accountID, userKey)
+		r.names[target] = name
+	}
+	if name == "" {
+		name = "unknown user"
+	}
+
+	report(models.ReferenceUserMention, target, models.ReferenceConvertedText)
+	return "@" + html.EscapeString(name)
+}
+
+// linkText returns the visible text of a link, or fallback when it has none
+func linkText(link, fallback string) string {
+	if m := linkBodyPattern.FindStringSubmatch(link); m != nil && strings.TrimSpace(m[1]) != "" {
+		if strings.Contains(link, "<ac:plain-text-link-body>") {
+			return html.EscapeString(m[1])
+		}
+		return m[1]
+	}
+	return fallback
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/cmd/confluence-service/api"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// contentTypes are the content types a structured search may be limited to
+var contentTypes = map[string]bool{"page": true, "blogpost": true, "comment": true, "attachment": true}
+
+// pageIDPattern matches content IDs
+var pageIDPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// structuredParams are the params of a structured search
+var structuredParams = []string{"text", "space_keys", "labels", "type", "author", "modified_since", "ancestor"}
+
+// handleSearch runs a raw CQL query, or builds one from the structured search params
+func (s *Service) handleSearch(client *api.Client, creds *models.WorkspaceCredentials, req models.ConfluenceRequest) map[string]interface{} {
+	query, _ := req.Params["query"].(string)
+
+	structured := false
+	for _, name := range structuredParams {
+		if isSet(req.Params[name]) {
+			structured = true
+		}
+	}
+	if query == "" && !structured {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing query or search fields", req.RequestID)
+	}
+	if query != "" && structured {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "query cannot be combined with search fields", req.RequestID)
+	}
+
+	page, err := atlassian.PageRequestFromParams(req.Params, 10)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+
+	if !structured {
+		results, err := client.SearchPages(query, page)
+		if err != nil {
+			return atlassian.ErrorResponse(err, req.RequestID)
+		}
+
+		return models.SuccessResponse(results, req.RequestID)
+	}
+
+	filter, err := searchFilter(req.Params)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+
+	cql, err := filter.CQL()
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+
+	hits, err := client.Search(cql, creds.BrowseURL(models.ProductConfluence), page)
+	if err != nil {
+		return atlassian.ErrorResponse(err, req.RequestID)
+	}
+
+	return models.SuccessResponse(hits, req.RequestID)
+}
+
+// isSet reports whether a param has a value; empty strings and lists count as unset
+func isSet(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// searchFilter reads and validates the structured search params
+func searchFilter(params map[string]interface{}) (api.SearchFilter, error) {
+	var filter api.SearchFilter
+	filter.Text, _ = params["text"].(string)
+	filter.SpaceKeys = stringList(params["space_keys"])
+	filter.Labels = stringList(params["labels"])
+	filter.Author, _ = params["author"].(string)
+
+	filter.Type, _ = params["type"].(string)
+	if filter.Type != "" && !contentTypes[filter.Type] {
+		return filter, fmt.Errorf("invalid type %q: must be page, blogpost, comment or attachment", filter.Type)
+	}
+
+	filter.Ancestor, _ = params["ancestor"].(string)
+	if filter.Ancestor != "" && !pageIDPattern.MatchString(filter.Ancestor) {
+		return filter, fmt.Errorf("invalid ancestor %q: must be a page ID", filter.Ancestor)
+	}
+
+	if since, _ := params["modified_since"].(string); since != "" {
+		t, err := parseSince(since)
+		if err != nil {
+			return filter, err
+		}
+		filter.ModifiedSince = t
+	}
+
+	return filter, nil
+}
+
+// parseSince reads a date (2006-01-02) or an RFC 3339 time. Times outside UTC are
+// refused by SearchFilter.CQL.
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid modified_since %q: use YYYY-MM-DD or an RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+// stringList reads a list of strings from a param, skipping items that are not strings
+func stringList(v interface{}) []string {
+	items, _ := v.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
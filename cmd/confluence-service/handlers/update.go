@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/cmd/confluence-service/api"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// headingPattern matches storage format headings; group 1 is the level, group 2 the content
+var headingPattern = regexp.MustCompile(`(?is)<h([1-6])(?:\s[^>]*)?>(.*?)</h[1-6]>`)
+
+// tagPattern matches markup inside heading content
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func (s *Service) handleUpdatePage(client *api.Client, req models.ConfluenceRequest) map[string]interface{} {
+	pageID, ok := req.Params["page_id"].(string)
+	if !ok || pageID == "" {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing page_id", req.RequestID)
+	}
+
+	body, ok := req.Params["body"].(string)
+	if !ok {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing body", req.RequestID)
+	}
+
+	mode, _ := req.Params["mode"].(string)
+	if mode == "" {
+		mode = models.UpdateModeReplace
+	}
+	section, _ := req.Params["section"].(string)
+	if mode == models.UpdateModeSection && strings.TrimSpace(section) == "" {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, "section mode requires section (the heading text)", req.RequestID)
+	}
+
+	format, err := bodyFormat(req)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+	body = toStorage(body, format)
+
+	title, _ := req.Params["title"].(string)
+	versionComment, _ := req.Params["version_comment"].(string)
+
+	expectedVersion := 0
+	if v, ok := req.Params["version"].(float64); ok {
+		expectedVersion = int(v)
+	}
+
+	page, err := client.GetPage(pageID)
+	if err != nil {
+		return atlassian.ErrorResponse(err, req.RequestID)
+	}
+
+	if expectedVersion > 0 && expectedVersion != page.Version.Number {
+		return versionConflict(pageID, expectedVersion, page.Version.Number, req.RequestID)
+	}
+
+	var newBody string
+	switch mode {
+	case models.UpdateModeReplace:
+		newBody = body
+	case models.UpdateModeAppend:
+		newBody = page.Body.Storage.Value + body
+	case models.UpdateModeSection:
+		newBody, err = replaceSection(page.Body.Storage.Value, section, body)
+		if err != nil {
+			return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+		}
+	default:
+		return models.ErrorResponse(models.ErrCodeInvalidRequest,
+			fmt.Sprintf("invalid mode %q (expected %s, %s or %s)", mode,
+				models.UpdateModeReplace, models.UpdateModeAppend, models.UpdateModeSection), req.RequestID)
+	}
+
+	if title == "" {
+		title = page.Title
+	}
+
+	updated, err := client.UpdatePage(pageID, title, newBody, page.Version.Number+1, versionComment, nil)
+	if err != nil {
+		// Someone saved the page between our read and write
+		if atlassian.ErrorCode(err) == models.ErrCodeConflict {
+			if current, getErr := client.GetPage(pageID); getErr == nil {
+				return versionConflict(pageID, page.Version.Number, current.Version.Number, req.RequestID)
+			}
+		}
+		return atlassian.ErrorResponse(err, req.RequestID)
+	}
+
+	return models.SuccessResponse(formatPage(updated, format), req.RequestID)
+}
+
+// versionConflict reports an update based on a stale version of a page
+func versionConflict(pageID string, expected, current int, requestID string) map[string]interface{} {
+	return models.ErrorResponseWithDetails(models.ErrCodeConflict,
+		fmt.Sprintf("page %s is at version %d, not %d; fetch it again and reapply the change against version %d",
+			pageID, current, expected, current),
+		&models.VersionConflict{
+			PageID:          pageID,
+			ExpectedVersion: expected,
+			CurrentVersion:  current,
+		}, requestID)
+}
+
+// replaceSection replaces the content under the heading whose text matches section
+// (case-insensitive), up to the next heading of the same or a higher level. The
+// heading itself is kept.
+func replaceSection(storage, section, body string) (string, error) {
+	want := normalizeHeading(section)
+	headings := headingPattern.FindAllStringSubmatchIndex(storage, -1)
+
+	for i, h := range headings {
+		if normalizeHeading(storage[h[4]:h[5]]) != want {
+			continue
+		}
+
+		level := storage[h[2]:h[3]]
+		end := len(storage)
+		for _, next := range headings[i+1:] {
+			if storage[next[2]:next[3]] <= level {
+				end = next[0]
+				break
+			}
+		}
+
+		return storage[:h[1]] + body + storage[end:], nil
+	}
+
+	return "", fmt.Errorf("section %q not found on the page", section)
+}
+
+// normalizeHeading reduces heading markup to comparable plain text
+func normalizeHeading(s string) string {
+	text := html.UnescapeString(tagPattern.ReplaceAllString(s, ""))
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
@@ -1,135 +1,212 @@
 package api
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"strings"
 
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/adf"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
 )
 
-// WorkspaceCredentials holds connection info for one Atlassian instance
-type WorkspaceCredentials struct {
-	Site  string // e.g., "https://eso.atlassian.net"
-	Email string // e.g., "service@eso.com"
-	Token string // Atlassian API token
-}
-
-// Client wraps HTTP client with Atlassian auth
+// Client is a Jira REST client
 type Client struct {
-	creds      WorkspaceCredentials
-	httpClient *http.Client
+	*atlassian.Client
 }
 
 // NewClient creates an authenticated Jira client
-func NewClient(creds WorkspaceCredentials) *Client {
+func NewClient(creds atlassian.Credentials) *Client {
+	return NewClientWithHTTPClient(creds, nil)
+}
+
+// NewClientWithHTTPClient creates an authenticated Jira client that sends
+// requests through a shared HTTP client
+func NewClientWithHTTPClient(creds atlassian.Credentials, httpClient *http.Client) *Client {
 	return &Client{
-		creds:      creds,
-		httpClient: &http.Client{},
+		Client: atlassian.NewClient(creds, httpClient),
 	}
 }
 
-// authHeader returns the Basic auth header value
-func (c *Client) authHeader() string {
-	credentials := fmt.Sprintf("%s:%s", c.creds.Email, c.creds.Token)
-	encoded := base64.StdEncoding.EncodeToString([]byte(credentials))
-	return "Basic " + encoded
+// apiBase returns the REST API root: /rest/api/3 on Cloud, /rest/api/2 on Data Center
+func (c *Client) apiBase() string {
+	if c.IsDataCenter() {
+		return c.Site() + "/rest/api/2"
+	}
+	return c.Site() + "/rest/api/3"
 }
 
-// SearchIssues searches for issues using JQL
-func (c *Client) SearchIssues(jql string, fields []string, limit int) (*models.SearchResponse, error) {
-	url := fmt.Sprintf("%s/rest/api/3/search", c.creds.Site)
+// searchPageSize is the most issues requested per page when walking search results
+const searchPageSize = 100
 
-	payload := map[string]interface{}{
-		"jql":        jql,
-		"maxResults": limit,
-		"startAt":    0,
-	}
+// defaultSearchFields are returned when a search names no fields. Cloud's /search/jql
+// would otherwise return only issue IDs.
+var defaultSearchFields = []string{"*navigable"}
 
-	if len(fields) > 0 {
-		payload["fields"] = fields
+// SearchOptions selects what a search returns for each issue
+type SearchOptions struct {
+	Fields       []string // Fields to return; empty returns the navigable fields
+	Expand       []string // Entities to expand, such as renderedFields or changelog
+	Properties   []string // Issue properties to return
+	FieldsByKeys bool     // Whether Fields holds field keys rather than IDs
+	CountTotal   bool     // Count the matching issues on Cloud, at the cost of another request
+}
+
+// payload returns the options as search request fields
+func (o SearchOptions) payload() map[string]interface{} {
+	payload := map[string]interface{}{"fields": defaultSearchFields}
+	if len(o.Fields) > 0 {
+		payload["fields"] = o.Fields
+	}
+	if len(o.Expand) > 0 {
+		payload["expand"] = strings.Join(o.Expand, ",")
+	}
+	if len(o.Properties) > 0 {
+		payload["properties"] = o.Properties
 	}
+	if o.FieldsByKeys {
+		payload["fieldsByKeys"] = true
+	}
+	return payload
+}
 
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
+// SearchIssues searches for issues using JQL, walking result pages as the page
+// request selects. Cloud uses the token-paginated /search/jql endpoint, which reports
+// no total unless opts.CountTotal asks for one; Data Center, which lacks it, uses
+// /search.
+func (c *Client) SearchIssues(jql string, opts SearchOptions, page atlassian.PageRequest) (*models.SearchResponse, error) {
+	results := &models.SearchResponse{MaxResults: page.Limit, Issues: []models.JiraIssue{}}
+
+	search := c.searchJQL
+	if c.IsDataCenter() {
+		search = c.searchOffset
+		results.StartAt = &page.Cursor.Start
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonPayload))
+	next, err := page.Collect(searchPageSize, func(cursor atlassian.Cursor, limit int) (int, *atlassian.Cursor, error) {
+		payload := opts.payload()
+		payload["jql"] = jql
+		payload["maxResults"] = limit
+
+		return search(payload, cursor, results)
+	})
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	// The token endpoint reports no total; count the first page's search instead
+	if opts.CountTotal && !c.IsDataCenter() && page.Cursor.Token == "" {
+		if count, err := c.CountIssues(jql); err == nil {
+			results.Total = count
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to search issues: %s", string(body))
+	results.NextCursor = atlassian.NextCursor(next)
+	return results, nil
+}
+
+// searchJQL fetches one page from /search/jql into results and returns the cursor of
+// the next page
+func (c *Client) searchJQL(payload map[string]interface{}, cursor atlassian.Cursor, results *models.SearchResponse) (int, *atlassian.Cursor, error) {
+	if cursor.Token != "" {
+		payload["nextPageToken"] = cursor.Token
 	}
 
-	var searchResp models.SearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, err
+	var searchResp struct {
+		Issues        []models.JiraIssue `json:"issues"`
+		NextPageToken string             `json:"nextPageToken"`
+		IsLast        bool               `json:"isLast"`
+	}
+	if err := c.postSearch(fmt.Sprintf("%s/search/jql", c.apiBase()), payload, &searchResp); err != nil {
+		return 0, nil, err
 	}
 
-	return &searchResp, nil
+	results.Issues = append(results.Issues, searchResp.Issues...)
+	if searchResp.IsLast || searchResp.NextPageToken == "" {
+		return len(searchResp.Issues), nil, nil
+	}
+	return len(searchResp.Issues), &atlassian.Cursor{Token: searchResp.NextPageToken}, nil
 }
 
-// GetIssue gets a specific issue by key or ID
-func (c *Client) GetIssue(issueKey string, expand []string) (*models.JiraIssue, error) {
-	url := fmt.Sprintf("%s/rest/api/3/issue/%s", c.creds.Site, issueKey)
+// searchOffset fetches one page from /search into results and returns the cursor of
+// the next page
+func (c *Client) searchOffset(payload map[string]interface{}, cursor atlassian.Cursor, results *models.SearchResponse) (int, *atlassian.Cursor, error) {
+	payload["startAt"] = cursor.Start
 
-	if len(expand) > 0 {
-		expandStr := ""
-		for i, e := range expand {
-			if i > 0 {
-				expandStr += ","
-			}
-			expandStr += e
-		}
-		url += "?expand=" + expandStr
+	var searchResp models.SearchResponse
+	if err := c.postSearch(fmt.Sprintf("%s/search", c.apiBase()), payload, &searchResp); err != nil {
+		return 0, nil, err
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	results.Issues = append(results.Issues, searchResp.Issues...)
+	results.Total = searchResp.Total
+
+	end := cursor.Start + len(searchResp.Issues)
+	if len(searchResp.Issues) == 0 || end >= searchResp.Total {
+		return len(searchResp.Issues), nil, nil
+	}
+	return len(searchResp.Issues), &atlassian.Cursor{Start: end}, nil
+}
+
+// postSearch posts a search request
+func (c *Client) postSearch(url string, payload map[string]interface{}, out interface{}) error {
+	req, err := c.NewRequest("POST", url, payload)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	// A search changes nothing, so it may be retried
+	return c.DoJSON(atlassian.Idempotent(req), "search issues", out)
+}
+
+// CountIssues returns the approximate number of issues matching JQL. Cloud only.
+func (c *Client) CountIssues(jql string) (int, error) {
+	req, err := c.NewRequest("POST", fmt.Sprintf("%s/search/approximate-count", c.apiBase()),
+		map[string]interface{}{"jql": jql})
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to get issue %s: %s", issueKey, string(body))
+	var count struct {
+		Count int `json:"count"`
+	}
+	if err := c.DoJSON(atlassian.Idempotent(req), "count issues", &count); err != nil {
+		return 0, err
+	}
+
+	return count.Count, nil
+}
+
+// GetIssue gets a specific issue by key or ID
+func (c *Client) GetIssue(issueKey string, expand []string) (*models.JiraIssue, error) {
+	url := fmt.Sprintf("%s/issue/%s", c.apiBase(), issueKey)
+	if len(expand) > 0 {
+		url += "?expand=" + strings.Join(expand, ",")
 	}
 
 	var issue models.JiraIssue
-	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+	if err := c.GetJSON(url, fmt.Sprintf("get issue %s", issueKey), &issue); err != nil {
 		return nil, err
 	}
 
 	return &issue, nil
 }
 
-// CreateIssue creates a new issue
-func (c *Client) CreateIssue(projectKey, issueType, summary, description string, additionalFields map[string]interface{}) (*models.JiraIssue, error) {
-	url := fmt.Sprintf("%s/rest/api/3/issue", c.creds.Site)
+// RichText returns text in the form description and comment fields take: an ADF
+// document on Cloud, or the text itself on Data Center, whose API v2 takes strings
+func (c *Client) RichText(text, format string) interface{} {
+	switch {
+	case c.IsDataCenter():
+		return text
+	case format == models.TextFormatPlain:
+		return adf.FromText(text)
+	default:
+		return adf.FromMarkdown(text)
+	}
+}
 
+// CreateIssue creates a new issue. description comes from RichText; nil leaves it unset.
+func (c *Client) CreateIssue(projectKey, issueType, summary string, description interface{}, additionalFields map[string]interface{}) (*models.JiraIssue, error) {
 	fields := map[string]interface{}{
 		"project": map[string]string{
 			"key": projectKey,
@@ -137,8 +214,10 @@ func (c *Client) CreateIssue(projectKey, issueType, summary, description string,
 		"issuetype": map[string]string{
 			"name": issueType,
 		},
-		"summary":     summary,
-		"description": description,
+		"summary": summary,
+	}
+	if description != nil {
+		fields["description"] = description
 	}
 
 	// Merge additional fields
@@ -150,32 +229,8 @@ func (c *Client) CreateIssue(projectKey, issueType, summary, description string,
 		Fields: fields,
 	}
 
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonPayload))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to create issue: %s", string(body))
-	}
-
 	var issue models.JiraIssue
-	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+	if err := c.SendJSON("POST", fmt.Sprintf("%s/issue", c.apiBase()), "create issue", payload, &issue); err != nil {
 		return nil, err
 	}
 
@@ -184,73 +239,23 @@ func (c *Client) CreateIssue(projectKey, issueType, summary, description string,
 
 // UpdateIssue updates an existing issue
 func (c *Client) UpdateIssue(issueKey string, fields map[string]interface{}) error {
-	url := fmt.Sprintf("%s/rest/api/3/issue/%s", c.creds.Site, issueKey)
-
 	payload := models.UpdateIssueRequest{
 		Fields: fields,
 	}
 
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("PUT", url, bytes.NewReader(jsonPayload))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to update issue %s: %s", issueKey, string(body))
-	}
-
-	return nil
+	return c.SendJSON("PUT", fmt.Sprintf("%s/issue/%s", c.apiBase(), issueKey),
+		fmt.Sprintf("update issue %s", issueKey), payload, nil)
 }
 
-// AddComment adds a comment to an issue
-func (c *Client) AddComment(issueKey, body string) (*models.Comment, error) {
-	url := fmt.Sprintf("%s/rest/api/3/issue/%s/comment", c.creds.Site, issueKey)
-
+// AddComment adds a comment to an issue. body comes from RichText.
+func (c *Client) AddComment(issueKey string, body interface{}) (*models.Comment, error) {
 	payload := map[string]interface{}{
 		"body": body,
 	}
 
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonPayload))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("failed to add comment: %s", string(body))
-	}
-
 	var comment models.Comment
-	if err := json.NewDecoder(resp.Body).Decode(&comment); err != nil {
+	if err := c.SendJSON("POST", fmt.Sprintf("%s/issue/%s/comment", c.apiBase(), issueKey),
+		"add comment", payload, &comment); err != nil {
 		return nil, err
 	}
 
@@ -259,38 +264,22 @@ func (c *Client) AddComment(issueKey, body string) (*models.Comment, error) {
 
 // TransitionIssue transitions an issue to a different status
 func (c *Client) TransitionIssue(issueKey, transitionID string) error {
-	url := fmt.Sprintf("%s/rest/api/3/issue/%s/transitions", c.creds.Site, issueKey)
-
 	payload := map[string]interface{}{
 		"transition": map[string]string{
 			"id": transitionID,
 		},
 	}
 
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonPayload))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", c.authHeader())
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	return c.SendJSON("POST", fmt.Sprintf("%s/issue/%s/transitions", c.apiBase(), issueKey),
+		fmt.Sprintf("transition issue %s", issueKey), payload, nil)
+}
 
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to transition issue %s: %s", issueKey, string(body))
+// GetMyself returns the user the client is authenticated as
+func (c *Client) GetMyself() (*models.User, error) {
+	var user models.User
+	if err := c.GetJSON(fmt.Sprintf("%s/myself", c.apiBase()), "get current user", &user); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &user, nil
 }
-
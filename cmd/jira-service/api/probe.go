@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// probePermissions are the global permissions the Jira tools rely on
+var probePermissions = []string{
+	"BROWSE_PROJECTS",
+	"CREATE_ISSUES",
+	"EDIT_ISSUES",
+	"ADD_COMMENTS",
+	"TRANSITION_ISSUES",
+}
+
+// Probe checks authentication, server availability and permissions with live requests.
+// It never returns an error; failures are reported in the health status.
+func (c *Client) Probe() *models.ProductHealth {
+	health := &models.ProductHealth{
+		Product:  models.ProductJira,
+		BaseURL:  c.Site(),
+		AuthType: c.Credentials().AuthType,
+	}
+
+	// Authentication and identity
+	resp, body, latency, err := c.probeGet(fmt.Sprintf("%s/myself", c.apiBase()))
+	if err != nil {
+		health.Status = models.HealthUnreachable
+		if errors.Is(err, atlassian.ErrAuthorize) {
+			health.Status = models.HealthAuthFailed
+		}
+		health.Error = err.Error()
+		return health
+	}
+	health.LatencyMS = latency.Milliseconds()
+	health.RateLimit = models.RateLimitFromHeaders(resp.Header)
+	health.Status = models.HealthFromStatusCode(resp.StatusCode)
+	if health.Status != models.HealthOK {
+		health.Error = fmt.Sprintf("GET /myself returned status %d", resp.StatusCode)
+		return health
+	}
+
+	var user models.User
+	if err := json.Unmarshal(body, &user); err == nil {
+		accountID := user.AccountID
+		if accountID == "" {
+			accountID = user.Name
+		}
+		health.Account = &models.CredentialCheck{
+			Product:     models.ProductJira,
+			BaseURL:     c.Site(),
+			AccountID:   accountID,
+			DisplayName: user.DisplayName,
+			Email:       user.Email,
+		}
+	}
+
+	var problems []string
+
+	// Server version
+	resp, body, _, err = c.probeGet(fmt.Sprintf("%s/serverInfo", c.apiBase()))
+	if err == nil && resp.StatusCode == http.StatusOK {
+		var info struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(body, &info) == nil {
+			health.Version = info.Version
+		}
+	} else {
+		problems = append(problems, "server info unavailable")
+	}
+
+	// Permissions held by the account
+	url := fmt.Sprintf("%s/mypermissions?permissions=%s", c.apiBase(), strings.Join(probePermissions, ","))
+	resp, body, _, err = c.probeGet(url)
+	if err == nil && resp.StatusCode == http.StatusOK {
+		var result struct {
+			Permissions map[string]struct {
+				HavePermission bool `json:"havePermission"`
+			} `json:"permissions"`
+		}
+		if json.Unmarshal(body, &result) == nil {
+			health.Permissions = make(map[string]bool, len(result.Permissions))
+			for key, p := range result.Permissions {
+				health.Permissions[key] = p.HavePermission
+			}
+		}
+		if !health.Permissions["BROWSE_PROJECTS"] {
+			problems = append(problems, "account cannot browse projects")
+		}
+	} else {
+		problems = append(problems, "permissions could not be checked")
+	}
+
+	if health.RateLimit != nil && health.RateLimit.NearLimit {
+		problems = append(problems, "rate limit nearly exhausted")
+	}
+
+	if len(problems) > 0 {
+		health.Status = models.HealthDegraded
+		health.Error = strings.Join(problems, "; ")
+	}
+
+	return health
+}
+
+// probeGet performs an authenticated GET and returns the raw response, body and latency
+func (c *Client) probeGet(url string) (*http.Response, []byte, time.Duration, error) {
+	req, err := c.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	// Report throttling as it is rather than waiting it out
+	req = atlassian.NoRetry(req)
+
+	start := time.Now()
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	latency := time.Since(start)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return resp, body, latency, nil
+}
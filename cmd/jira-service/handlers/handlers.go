@@ -3,9 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/providentiaww/trilix-atlassian-mcp/cmd/jira-service/api"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/oauth"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -13,15 +16,29 @@ import (
 // Service handles Jira service requests
 type Service struct {
 	credStore storage.CredentialStoreInterface
+	clients   *api.ClientPool
+	oauth     *oauth.Config // Optional; needed to refresh OAuth 2.0 tokens
 }
 
 // NewService creates a new Jira service
-func NewService(credStore storage.CredentialStoreInterface) *Service {
+func NewService(credStore storage.CredentialStoreInterface, clients *api.ClientPool, oauthConfig *oauth.Config) *Service {
 	return &Service{
 		credStore: credStore,
+		clients:   clients,
+		oauth:     oauthConfig,
 	}
 }
 
+// apiCredentials converts stored credentials into Jira client credentials
+func (s *Service) apiCredentials(userID, workspaceID string, creds *models.WorkspaceCredentials) atlassian.Credentials {
+	var tokenSource atlassian.TokenSource
+	if creds.AuthKind() == models.AuthTypeOAuth2 && creds.OAuth != nil {
+		tokenSource = oauth.NewRefreshingTokenSource(s.oauth, s.credStore, userID, workspaceID, *creds.OAuth)
+	}
+
+	return atlassian.CredentialsFor(creds, models.ProductJira, tokenSource)
+}
+
 // HandleRequest processes incoming RabbitMQ messages
 func (s *Service) HandleRequest(d amqp.Delivery) []byte {
 	var req models.JiraRequest
@@ -31,21 +48,31 @@ func (s *Service) HandleRequest(d amqp.Delivery) []byte {
 		return responseBytes
 	}
 
+	// Validation checks a product even before it is enabled for the workspace
+	if req.Action == "validate_credentials" {
+		responseBytes, _ := json.Marshal(s.handleValidateCredentials(req))
+		return responseBytes
+	}
+
 	// Get credentials for the workspace
 	creds, err := s.credStore.GetCredentials(req.UserID, req.WorkspaceID)
 	if err != nil {
+		s.clients.Invalidate(req.UserID, req.WorkspaceID)
 		response := models.ErrorResponse(models.ErrCodeAuthFailed,
 			fmt.Sprintf("workspace not found: %s", req.WorkspaceID), req.RequestID)
 		responseBytes, _ := json.Marshal(response)
 		return responseBytes
 	}
 
-	// Create API client
-	client := api.NewClient(api.WorkspaceCredentials{
-		Site:  creds.Site,
-		Email: creds.Email,
-		Token: creds.Token,
-	})
+	if !creds.HasProduct(models.ProductJira) {
+		response := models.ErrorResponse(models.ErrCodeProductDisabled,
+			fmt.Sprintf("Jira is not enabled for workspace %s", req.WorkspaceID), req.RequestID)
+		responseBytes, _ := json.Marshal(response)
+		return responseBytes
+	}
+
+	// Get a pooled API client (rebuilt automatically if the credentials changed)
+	client := s.clients.Get(req.UserID, req.WorkspaceID, s.apiCredentials(req.UserID, req.WorkspaceID, creds))
 
 	// Route to appropriate handler
 	var response map[string]interface{}
@@ -62,6 +89,8 @@ func (s *Service) HandleRequest(d amqp.Delivery) []byte {
 		response = s.handleAddComment(client, req)
 	case "transition_issue":
 		response = s.handleTransitionIssue(client, req)
+	case "health_check":
+		response = s.handleHealthCheck(client, creds, req)
 	default:
 		response = models.ErrorResponse(models.ErrCodeInvalidRequest,
 			fmt.Sprintf("unknown action: %s", req.Action), req.RequestID)
@@ -71,28 +100,81 @@ func (s *Service) HandleRequest(d amqp.Delivery) []byte {
 	return responseBytes
 }
 
+// handleValidateCredentials checks a stored workspace's credentials against /myself.
+// Unsaved API tokens are validated by the MCP server itself and never reach the service.
+func (s *Service) handleValidateCredentials(req models.JiraRequest) map[string]interface{} {
+	creds, err := s.credStore.GetCredentials(req.UserID, req.WorkspaceID)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeAuthFailed,
+			fmt.Sprintf("workspace not found: %s", req.WorkspaceID), req.RequestID)
+	}
+
+	apiCreds := s.apiCredentials(req.UserID, req.WorkspaceID, creds)
+	user, err := s.clients.NewClient(apiCreds).GetMyself()
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeAuthFailed, err.Error(), req.RequestID)
+	}
+
+	accountID := user.AccountID
+	if accountID == "" {
+		accountID = user.Name
+	}
+
+	return models.SuccessResponse(models.CredentialCheck{
+		Product:     models.ProductJira,
+		BaseURL:     apiCreds.Site,
+		AccountID:   accountID,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+	}, req.RequestID)
+}
+
+// handleHealthCheck probes the workspace with live requests and reports its health
+func (s *Service) handleHealthCheck(client *api.Client, creds *models.WorkspaceCredentials, req models.JiraRequest) map[string]interface{} {
+	health := client.Probe()
+	if creds.OAuth != nil {
+		health.Scopes = strings.Fields(creds.OAuth.Scope)
+	}
+	return models.SuccessResponse(health, req.RequestID)
+}
+
+// stringList reads a list of strings from a param, skipping items that are not strings
+func stringList(v interface{}) []string {
+	items, _ := v.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
 func (s *Service) handleListIssues(client *api.Client, req models.JiraRequest) map[string]interface{} {
 	jql, ok := req.Params["jql"].(string)
 	if !ok {
 		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing jql", req.RequestID)
 	}
 
-	limit := 50
-	if l, ok := req.Params["limit"].(float64); ok {
-		limit = int(l)
+	page, err := atlassian.PageRequestFromParams(req.Params, 50)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
 	}
 
-	var fields []string
-	if f, ok := req.Params["fields"].([]interface{}); ok {
-		fields = make([]string, len(f))
-		for i, v := range f {
-			fields[i] = v.(string)
-		}
+	opts := api.SearchOptions{
+		Fields:     stringList(req.Params["fields"]),
+		Expand:     stringList(req.Params["expand"]),
+		Properties: stringList(req.Params["properties"]),
 	}
+	opts.FieldsByKeys, _ = req.Params["fields_by_keys"].(bool)
+	opts.CountTotal, _ = req.Params["count_total"].(bool)
 
-	results, err := client.SearchIssues(jql, fields, limit)
+	results, err := client.SearchIssues(jql, opts, page)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
+	}
+	for _, issue := range results.Issues {
+		markdownDocuments(issue.Fields)
 	}
 
 	return models.SuccessResponse(results, req.RequestID)
@@ -104,18 +186,11 @@ func (s *Service) handleGetIssue(client *api.Client, req models.JiraRequest) map
 		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing issue_key", req.RequestID)
 	}
 
-	var expand []string
-	if e, ok := req.Params["expand"].([]interface{}); ok {
-		expand = make([]string, len(e))
-		for i, v := range e {
-			expand[i] = v.(string)
-		}
-	}
-
-	issue, err := client.GetIssue(issueKey, expand)
+	issue, err := client.GetIssue(issueKey, stringList(req.Params["expand"]))
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
+	markdownDocuments(issue.Fields)
 
 	return models.SuccessResponse(issue, req.RequestID)
 }
@@ -136,20 +211,31 @@ func (s *Service) handleCreateIssue(client *api.Client, req models.JiraRequest)
 		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing summary", req.RequestID)
 	}
 
-	description := ""
-	if d, ok := req.Params["description"].(string); ok {
-		description = d
+	format, err := textFormat(req)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+
+	var description interface{}
+	if d, ok := req.Params["description"].(string); ok && d != "" {
+		description = client.RichText(d, format)
 	}
 
-	// Additional fields
+	// Additional fields; rich text in them is converted like the description, which
+	// they must not replace
 	additionalFields := make(map[string]interface{})
 	if af, ok := req.Params["additional_fields"].(map[string]interface{}); ok {
 		additionalFields = af
 	}
+	if _, ok := additionalFields["description"]; ok && description != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest,
+			"description given both as a param and in additional_fields", req.RequestID)
+	}
+	convertRichText(client, additionalFields, format)
 
 	issue, err := client.CreateIssue(projectKey, issueType, summary, description, additionalFields)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
 
 	return models.SuccessResponse(issue, req.RequestID)
@@ -166,9 +252,16 @@ func (s *Service) handleUpdateIssue(client *api.Client, req models.JiraRequest)
 		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing fields", req.RequestID)
 	}
 
-	err := client.UpdateIssue(issueKey, fields)
+	format, err := textFormat(req)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+
+	convertRichText(client, fields, format)
+
+	err = client.UpdateIssue(issueKey, fields)
+	if err != nil {
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
 
 	return models.SuccessResponse(map[string]string{"status": "updated"}, req.RequestID)
@@ -185,10 +278,16 @@ func (s *Service) handleAddComment(client *api.Client, req models.JiraRequest) m
 		return models.ErrorResponse(models.ErrCodeInvalidRequest, "missing body", req.RequestID)
 	}
 
-	comment, err := client.AddComment(issueKey, body)
+	format, err := textFormat(req)
+	if err != nil {
+		return models.ErrorResponse(models.ErrCodeInvalidRequest, err.Error(), req.RequestID)
+	}
+
+	comment, err := client.AddComment(issueKey, client.RichText(body, format))
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
+	comment.Body = markdownDocuments(comment.Body)
 
 	return models.SuccessResponse(comment, req.RequestID)
 }
@@ -206,7 +305,7 @@ func (s *Service) handleTransitionIssue(client *api.Client, req models.JiraReque
 
 	err := client.TransitionIssue(issueKey, transitionID)
 	if err != nil {
-		return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), req.RequestID)
+		return atlassian.ErrorResponse(err, req.RequestID)
 	}
 
 	return models.SuccessResponse(map[string]string{"status": "transitioned"}, req.RequestID)
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/cmd/jira-service/api"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/adf"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// richTextFields are the system fields that hold rich text: ADF documents on Cloud
+var richTextFields = []string{"description", "environment"}
+
+// textFormat returns the format of descriptions and comments given by the format
+// param, Markdown by default
+func textFormat(req models.JiraRequest) (string, error) {
+	format, _ := req.Params["format"].(string)
+	switch format {
+	case "":
+		return models.TextFormatMarkdown, nil
+	case models.TextFormatMarkdown, models.TextFormatPlain:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid format %q (expected %s or %s)", format,
+			models.TextFormatMarkdown, models.TextFormatPlain)
+	}
+}
+
+// convertRichText converts the rich text fields given as strings in fields, such as an
+// update's fields or a create's additional_fields; ADF documents are sent as they are
+func convertRichText(client *api.Client, fields map[string]interface{}, format string) {
+	for _, name := range richTextFields {
+		if text, ok := fields[name].(string); ok {
+			fields[name] = client.RichText(text, format)
+		}
+	}
+}
+
+// markdownDocuments replaces the ADF documents in a decoded API value, such as an
+// issue's fields, with their Markdown rendering. Maps and slices are changed in place.
+func markdownDocuments(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if doc, ok := adf.Parse(t); ok {
+			return adf.ToMarkdown(doc)
+		}
+		for k, item := range t {
+			t[k] = markdownDocuments(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = markdownDocuments(item)
+		}
+	}
+	return v
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/joho/godotenv"
 	"github.com/providentiaww/twistygo"
+	"github.com/providentiaww/trilix-atlassian-mcp/cmd/jira-service/api"
 	"github.com/providentiaww/trilix-atlassian-mcp/cmd/jira-service/handlers"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/oauth"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -40,8 +42,22 @@ func main() {
 	}
 	defer credStore.Close()
 
+	// Create the API client pool (shared keep-alive connections per Atlassian site)
+	clientOpts, err := atlassian.ClientOptionsFromEnv()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to configure API clients: %v", err))
+	}
+	clients := api.NewClientPool(clientOpts)
+	defer clients.Close()
+
+	// OAuth 2.0 app credentials, used to refresh tokens of OAuth-connected workspaces
+	oauthConfig, err := oauth.ConfigFromEnv()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to configure OAuth: %v", err))
+	}
+
 	// Create service handler
-	service := handlers.NewService(credStore)
+	service := handlers.NewService(credStore, clients, oauthConfig)
 
 	// Get service handle
 	svc := rconn.AmqpConnectService("JiraService")
@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/oauth"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
+)
+
+// stateLifetime bounds how long a user may take on the Atlassian consent screen
+const stateLifetime = 15 * time.Minute
+
+// nonceCookie binds a consent flow to the browser that started it. The callback
+// only accepts a state whose nonce matches the cookie, so a callback URL started by
+// someone else cannot attach their Atlassian account to the victim's workspace.
+const nonceCookie = "trilix_oauth_nonce"
+
+// callbackPath is where Atlassian redirects after consent
+const callbackPath = "/oauth/atlassian/callback"
+
+// AtlassianOAuthHandler serves the Atlassian OAuth 2.0 (3LO) consent and callback
+// endpoints and stores the resulting tokens in the credential store
+type AtlassianOAuthHandler struct {
+	cfg       *oauth.Config
+	credStore storage.CredentialStoreInterface
+	clerk     *ClerkAuth
+	stateKey  []byte
+}
+
+// oauthState is carried through the consent flow, signed so it cannot be forged
+type oauthState struct {
+	UserID        string   `json:"u"`
+	WorkspaceID   string   `json:"w"`
+	WorkspaceName string   `json:"n,omitempty"`
+	Site          string   `json:"s,omitempty"`
+	Products      []string `json:"p,omitempty"`
+	ExpiresAt     int64    `json:"e"`
+	Nonce         string   `json:"x"` // Must match the nonce cookie of the browser completing the flow
+}
+
+// NewAtlassianOAuthHandler creates the OAuth endpoint handler
+func NewAtlassianOAuthHandler(cfg *oauth.Config, credStore storage.CredentialStoreInterface) *AtlassianOAuthHandler {
+	key := sha256.Sum256([]byte("trilix-oauth-state:" + cfg.ClientSecret))
+	return &AtlassianOAuthHandler{
+		cfg:       cfg,
+		credStore: credStore,
+		clerk:     NewClerkAuth(),
+		stateKey:  key[:],
+	}
+}
+
+// RegisterRoutes adds the OAuth endpoints to a mux
+func (h *AtlassianOAuthHandler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/oauth/atlassian/start", h.handleStart)
+	mux.HandleFunc(callbackPath, h.handleCallback)
+}
+
+// handleStart redirects the user to the Atlassian consent screen.
+//
+// Query parameters: workspace_id (required), workspace_name, site (the Atlassian
+// site URL to connect when the user has access to several) and products
+// (comma-separated). When Clerk is configured, the session token must be passed
+// as a bearer token or in the "token" parameter.
+func (h *AtlassianOAuthHandler) handleStart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.cfg.RedirectURL == "" {
+		http.Error(w, "ATLASSIAN_OAUTH_REDIRECT_URL is not configured", http.StatusInternalServerError)
+		return
+	}
+
+	userID, err := h.clerk.UserIDFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	workspaceID := strings.TrimSpace(query.Get("workspace_id"))
+	if workspaceID == "" {
+		http.Error(w, "workspace_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var products []string
+	if p := query.Get("products"); p != "" {
+		products = strings.Split(p, ",")
+	}
+
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		http.Error(w, "failed to create state", http.StatusInternalServerError)
+		return
+	}
+	nonce := base64.RawURLEncoding.EncodeToString(nonceBytes)
+
+	state, err := h.signState(oauthState{
+		UserID:        userID,
+		WorkspaceID:   workspaceID,
+		WorkspaceName: query.Get("workspace_name"),
+		Site:          strings.TrimSuffix(query.Get("site"), "/"),
+		Products:      products,
+		ExpiresAt:     time.Now().Add(stateLifetime).Unix(),
+		Nonce:         nonce,
+	})
+	if err != nil {
+		http.Error(w, "failed to create state", http.StatusInternalServerError)
+		return
+	}
+
+	// Lax cookies are still sent on the top-level redirect back from Atlassian
+	http.SetCookie(w, h.flowCookie(nonce, int(stateLifetime.Seconds())))
+
+	http.Redirect(w, r, h.cfg.AuthCodeURL(state), http.StatusFound)
+}
+
+// handleCallback exchanges the authorization code and saves the workspace credentials
+func (h *AtlassianOAuthHandler) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if errCode := query.Get("error"); errCode != "" {
+		http.Error(w, fmt.Sprintf("authorization failed: %s %s", errCode, query.Get("error_description")),
+			http.StatusBadRequest)
+		return
+	}
+
+	state, err := h.verifyState(query.Get("state"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cookie, err := r.Cookie(nonceCookie)
+	if err != nil || !hmac.Equal([]byte(cookie.Value), []byte(state.Nonce)) {
+		http.Error(w, "authorization was started in another browser session, please start again", http.StatusBadRequest)
+		return
+	}
+
+	// Each nonce completes one flow
+	http.SetCookie(w, h.flowCookie("", -1))
+
+	code := query.Get("code")
+	if code == "" {
+		http.Error(w, "missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.cfg.Exchange(code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	resources, err := h.cfg.AccessibleResources(token.AccessToken)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	resource, err := selectResource(resources, state.Site)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The profile is informational; a missing read:me scope should not block the connection
+	email := ""
+	if profile, err := h.cfg.Me(token.AccessToken); err == nil {
+		email = profile.Email
+	}
+
+	workspaceName := state.WorkspaceName
+	if workspaceName == "" {
+		workspaceName = resource.Name
+	}
+
+	cred := &models.AtlassianCredential{
+		UserID:        state.UserID,
+		WorkspaceID:   state.WorkspaceID,
+		WorkspaceName: workspaceName,
+		AtlassianURL:  resource.URL,
+		Products:      state.Products,
+		Deployment:    models.DeploymentCloud,
+		AuthType:      models.AuthTypeOAuth2,
+		CloudID:       resource.ID,
+		Email:         email,
+		OAuth:         token,
+	}
+
+	if err := h.credStore.SaveCredentials(cred); err != nil {
+		http.Error(w, fmt.Sprintf("failed to save workspace: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<p>Workspace <b>%s</b> is connected to %s. You can close this window.</p>",
+		html.EscapeString(state.WorkspaceID), html.EscapeString(resource.URL))
+}
+
+// flowCookie returns the flow nonce cookie, scoped to the registered callback URL
+func (h *AtlassianOAuthHandler) flowCookie(value string, maxAge int) *http.Cookie {
+	path := callbackPath
+	if u, err := url.Parse(h.cfg.RedirectURL); err == nil && u.Path != "" {
+		path = u.Path
+	}
+
+	return &http.Cookie{
+		Name:     nonceCookie,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.cfg.RedirectURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// signState serializes and signs the flow state
+func (h *AtlassianOAuthHandler) signState(state oauthState) (string, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, h.stateKey)
+	mac.Write([]byte(encoded))
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifyState checks the signature and expiry of a flow state
+func (h *AtlassianOAuthHandler) verifyState(raw string) (*oauthState, error) {
+	encoded, signature, ok := strings.Cut(raw, ".")
+	if !ok {
+		return nil, errors.New("invalid state")
+	}
+
+	mac := hmac.New(sha256.New, h.stateKey)
+	mac.Write([]byte(encoded))
+	expected := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, errors.New("invalid state signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid state")
+	}
+
+	var state oauthState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return nil, errors.New("invalid state")
+	}
+	if time.Now().Unix() > state.ExpiresAt {
+		return nil, errors.New("authorization request expired, please start again")
+	}
+
+	return &state, nil
+}
+
+// selectResource picks the site to connect: the requested one, or the only one granted
+func selectResource(resources []oauth.Resource, site string) (*oauth.Resource, error) {
+	if len(resources) == 0 {
+		return nil, errors.New("the authorization did not grant access to any Atlassian site")
+	}
+
+	if site != "" {
+		for i := range resources {
+			if strings.EqualFold(strings.TrimSuffix(resources[i].URL, "/"), site) {
+				return &resources[i], nil
+			}
+		}
+		return nil, fmt.Errorf("the authorization did not grant access to %s", site)
+	}
+
+	// Without an explicit site the grant must cover exactly one cloud ID
+	for _, r := range resources[1:] {
+		if r.ID != resources[0].ID {
+			var sites []string
+			for _, r := range resources {
+				sites = append(sites, r.URL)
+			}
+			return nil, fmt.Errorf("access was granted to several sites (%s); start again with the site parameter",
+				strings.Join(sites, ", "))
+		}
+	}
+
+	return &resources[0], nil
+}
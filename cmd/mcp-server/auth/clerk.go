@@ -2,9 +2,11 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 )
 
 // ClerkAuth handles Clerk authentication
@@ -72,6 +74,29 @@ func (c *ClerkAuth) VerifyToken(token string) (*UserContext, error) {
 	}, nil
 }
 
+// UserIDFromRequest identifies the caller of an HTTP endpoint: the Clerk user when
+// Clerk is configured, otherwise the local single-user ID used by the stdio server.
+// The session token is read from a bearer Authorization header or the "token" query parameter.
+func (c *ClerkAuth) UserIDFromRequest(r *http.Request) (string, error) {
+	if c == nil {
+		return "", nil
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return "", errors.New("missing Clerk session token")
+	}
+
+	userCtx, err := c.VerifyToken(token)
+	if err != nil {
+		return "", err
+	}
+	return userCtx.UserID, nil
+}
+
 // ExtractUserID extracts user ID from request metadata
 func ExtractUserID(metadata map[string]interface{}) string {
 	if metadata == nil {
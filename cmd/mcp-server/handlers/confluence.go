@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync/atomic"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/providentiaww/trilix-atlassian-mcp/pkg/mcp"
 )
 
+// requestIDCounter generates correlation IDs shared by all service handlers
 var requestIDCounter int64
 
 // ConfluenceHandler handles Confluence-related MCP tool calls
@@ -40,13 +42,51 @@ func (h *ConfluenceHandler) ListTools() []mcp.Tool {
 						"type":        "string",
 						"description": "Confluence page ID",
 					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.BodyFormatStorage, models.BodyFormatMarkdown},
+						"description": "Body format to return: storage (Confluence XHTML) or markdown (shorter; macros without a Markdown equivalent are reduced to their text)",
+						"default":     models.BodyFormatStorage,
+					},
+				},
+				"required": []string{"workspace_id", "page_id"},
+			},
+		},
+		{
+			Name:        "confluence_get_children",
+			Description: "List the direct child pages of a Confluence page, in order, without their bodies. When more children exist, the response has a next_cursor to pass as cursor.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"workspace_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Workspace ID",
+					},
+					"page_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Parent page ID",
+					},
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of results (per call, or in total with fetch_all)",
+						"default":     50,
+					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "next_cursor from a previous call, to continue the listing",
+					},
+					"fetch_all": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Walk pages server-side until limit results were gathered (at most 1000)",
+						"default":     false,
+					},
 				},
 				"required": []string{"workspace_id", "page_id"},
 			},
 		},
 		{
 			Name:        "confluence_search",
-			Description: "Search for content in Confluence using CQL. Supports querying multiple workspaces - specify workspace_id to search a specific organization.",
+			Description: "Search for content in Confluence, either with a raw CQL query or with structured search fields (text, spaces, labels, type, author, modified_since, ancestor) that are combined with AND. Structured searches return an excerpt, the space, the last-modified time and the URL of each hit. Supports querying multiple workspaces - specify workspace_id to search a specific organization. When more results exist, the response has a next_cursor to pass as cursor.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -56,15 +96,59 @@ func (h *ConfluenceHandler) ListTools() []mcp.Tool {
 					},
 					"query": map[string]interface{}{
 						"type":        "string",
-						"description": "CQL search query",
+						"description": "Raw CQL search query. Leave empty to use the structured search fields instead",
+					},
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "Structured search: words to find in titles and bodies",
+					},
+					"space_keys": map[string]interface{}{
+						"type": "array",
+						"items": map[string]string{
+							"type": "string",
+						},
+						"description": "Structured search: keys of the spaces to search",
+					},
+					"labels": map[string]interface{}{
+						"type": "array",
+						"items": map[string]string{
+							"type": "string",
+						},
+						"description": "Structured search: labels the content must all have",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"page", "blogpost", "comment", "attachment"},
+						"description": "Structured search: content type",
+					},
+					"author": map[string]interface{}{
+						"type":        "string",
+						"description": "Structured search: account ID (username on Data Center) of the content's creator",
+					},
+					"modified_since": map[string]interface{}{
+						"type":        "string",
+						"description": "Structured search: earliest last-modified date (YYYY-MM-DD) or time (RFC 3339 in UTC, e.g. 2024-05-01T09:30:00Z; other offsets are rejected)",
+					},
+					"ancestor": map[string]interface{}{
+						"type":        "string",
+						"description": "Structured search: ID of a page the content must be below",
 					},
 					"limit": map[string]interface{}{
 						"type":        "number",
-						"description": "Maximum number of results",
+						"description": "Maximum number of results (per call, or in total with fetch_all)",
 						"default":     10,
 					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "next_cursor from a previous call, to continue the search",
+					},
+					"fetch_all": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Walk pages server-side until limit results were gathered (at most 1000)",
+						"default":     false,
+					},
 				},
-				"required": []string{"workspace_id", "query"},
+				"required": []string{"workspace_id"},
 			},
 		},
 		{
@@ -87,8 +171,15 @@ func (h *ConfluenceHandler) ListTools() []mcp.Tool {
 					},
 					"body": map[string]interface{}{
 						"type":        "string",
-						"description": "Page body (storage format)",
+						"description": "Page body, in the format given by format",
 					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.BodyFormatStorage, models.BodyFormatMarkdown},
+						"description": "Format of body: storage (Confluence XHTML) or markdown (converted to storage format; code blocks become code macros and > [!NOTE] alerts become info panels)",
+						"default":     models.BodyFormatStorage,
+					},
+
 					"parent_id": map[string]interface{}{
 						"type":        "string",
 						"description": "Optional parent page ID",
@@ -97,9 +188,60 @@ func (h *ConfluenceHandler) ListTools() []mcp.Tool {
 				"required": []string{"workspace_id", "space_key", "title", "body"},
 			},
 		},
+		{
+			Name:        "confluence_update_page",
+			Description: "Edit an existing Confluence page instead of creating a new one. Replace the whole body, append to it, or replace the content under one heading. Pass the version you read with confluence_get_page to detect concurrent edits.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"workspace_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Workspace ID",
+					},
+					"page_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Confluence page ID",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "New content, in the format given by format",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.BodyFormatStorage, models.BodyFormatMarkdown},
+						"description": "Format of body and of the returned page: storage (Confluence XHTML) or markdown",
+						"default":     models.BodyFormatStorage,
+					},
+
+					"mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.UpdateModeReplace, models.UpdateModeAppend, models.UpdateModeSection},
+						"description": "replace: body becomes the whole page; append: body is added at the end; section: body replaces the content under the heading named in section",
+						"default":     models.UpdateModeReplace,
+					},
+					"section": map[string]interface{}{
+						"type":        "string",
+						"description": "Heading text of the section to replace (section mode only)",
+					},
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "New page title (default: unchanged)",
+					},
+					"version": map[string]interface{}{
+						"type":        "number",
+						"description": "Version number the edit is based on; the update fails with the current version if the page has changed since",
+					},
+					"version_comment": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional comment shown in the page history",
+					},
+				},
+				"required": []string{"workspace_id", "page_id", "body"},
+			},
+		},
 		{
 			Name:        "confluence_copy_page",
-			Description: "Copy a page from one workspace to another",
+			Description: "Copy a page, or a whole page tree, from one workspace to another. Links between copied pages are pointed at the copies. Returns a map from source to destination page IDs and the references that could not be resolved in the destination.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -109,7 +251,7 @@ func (h *ConfluenceHandler) ListTools() []mcp.Tool {
 					},
 					"dst_workspace": map[string]interface{}{
 						"type":        "string",
-						"description": "Destination workspace ID",
+						"description": "Destination workspace ID (defaults to src_workspace, copying within one site)",
 					},
 					"src_page_id": map[string]interface{}{
 						"type":        "string",
@@ -123,13 +265,48 @@ func (h *ConfluenceHandler) ListTools() []mcp.Tool {
 						"type":        "string",
 						"description": "Optional parent page ID in destination",
 					},
+					"recursive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also copy all descendant pages, preserving hierarchy and order",
+						"default":     false,
+					},
+					"max_depth": map[string]interface{}{
+						"type":        "number",
+						"description": "With recursive, the deepest level of descendants to copy (1 = direct children only; default: no limit)",
+					},
+					"max_pages": map[string]interface{}{
+						"type":        "number",
+						"description": "With recursive, the most pages the copy may include, root included (default: 200, at most 1000). A larger tree is refused before anything is copied",
+					},
+					"attachments": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Transfer attachments and embedded images with each page",
+						"default":     true,
+					},
+					"on_conflict": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.CopyConflictFail, models.CopyConflictSkip, models.CopyConflictSuffix, models.CopyConflictOverwrite},
+						"description": "What to do when the destination space already has a page with the same title: fail, skip (keep it and copy children under it), suffix (append ' (copy)') or overwrite (replace its body and move it under the copy's parent). A page of the source tree itself, or one the copy already used, is never skipped to or overwritten; its title is suffixed instead",
+						"default":     models.CopyConflictFail,
+					},
+					"jira_macros": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.CopyMacrosLink, models.CopyMacrosKeep},
+						"description": "Jira issue macros: link (replace with links to the source Jira) or keep (leave as is; they only render if the destination links the same Jira)",
+						"default":     models.CopyMacrosLink,
+					},
+					"mentions": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.CopyMentionsText, models.CopyMentionsKeep},
+						"description": "User mentions: text (replace with the user's name) or keep. Default: text when either site is Data Center, keep between Cloud sites",
+					},
 				},
-				"required": []string{"src_workspace", "dst_workspace", "src_page_id", "dst_space_key"},
+				"required": []string{"src_workspace", "src_page_id", "dst_space_key"},
 			},
 		},
 		{
 			Name:        "confluence_list_spaces",
-			Description: "List all spaces in a workspace",
+			Description: "List the spaces in a workspace. When more spaces exist, the response has a next_cursor to pass as cursor.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -139,9 +316,18 @@ func (h *ConfluenceHandler) ListTools() []mcp.Tool {
 					},
 					"limit": map[string]interface{}{
 						"type":        "number",
-						"description": "Maximum number of results",
+						"description": "Maximum number of results (per call, or in total with fetch_all)",
 						"default":     50,
 					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "next_cursor from a previous call, to continue the listing",
+					},
+					"fetch_all": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Walk pages server-side until limit results were gathered (at most 1000)",
+						"default":     false,
+					},
 				},
 				"required": []string{"workspace_id"},
 			},
@@ -186,10 +372,10 @@ func (h *ConfluenceHandler) HandleTool(call mcp.ToolCall, userID string) (mcp.To
 		}
 		return mcp.ToolResult{
 			Content: []mcp.ContentBlock{
-				{Type: "text", Text: fmt.Sprintf("Error: %s", errorMsg)},
+				{Type: "text", Text: describeError(resp.Error)},
 			},
 			IsError: true,
-		}, fmt.Errorf(errorMsg)
+		}, errors.New(errorMsg)
 	}
 
 	// Convert response to JSON string
@@ -206,10 +392,14 @@ func getActionFromToolName(toolName string) string {
 	switch toolName {
 	case "confluence_get_page":
 		return "get_page"
+	case "confluence_get_children":
+		return "get_children"
 	case "confluence_search":
 		return "search"
 	case "confluence_create_page":
 		return "create_page"
+	case "confluence_update_page":
+		return "update_page"
 	case "confluence_copy_page":
 		return "copy_page"
 	case "confluence_list_spaces":
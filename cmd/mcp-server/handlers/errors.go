@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// errorHints tell agents what to do about each kind of failure
+var errorHints = map[string]string{
+	models.ErrCodeAuthFailed:  "The workspace credentials were rejected; check them with validate_workspace and fix them with update_workspace.",
+	models.ErrCodeForbidden:   "The workspace account lacks permission for this; use another workspace or ask an Atlassian admin for access.",
+	models.ErrCodeNotFound:    "Check the key or ID; Atlassian also reports items the account cannot see as not found.",
+	models.ErrCodeConflict:    "Someone else changed it in the meantime; read it again, reapply your change and retry with the current version.",
+	models.ErrCodeTimeout:     "The service did not answer in time; retry shortly, and check workspace_status if it persists.",
+	models.ErrCodeUnavailable: "The service is unavailable; retry shortly, and check workspace_status if it persists.",
+}
+
+// describeError renders a service error for agents: what failed, each reason on its
+// own line (field errors named), and a hint on how to recover
+func describeError(info *models.ErrorInfo) string {
+	if info == nil {
+		return "Error: Unknown error"
+	}
+
+	var details models.APIErrorDetails
+	if info.Details != nil {
+		detailsJSON, _ := json.Marshal(info.Details)
+		json.Unmarshal(detailsJSON, &details)
+	}
+
+	var sb strings.Builder
+	if details.Operation == "" {
+		fmt.Fprintf(&sb, "Error: %s", info.Message)
+	} else {
+		fmt.Fprintf(&sb, "Error: could not %s (%s)", details.Operation, info.Code)
+		if len(details.Messages) == 0 && len(details.FieldErrors) == 0 {
+			fmt.Fprintf(&sb, "\n- %s", info.Message)
+		}
+		for _, msg := range details.Messages {
+			fmt.Fprintf(&sb, "\n- %s", msg)
+		}
+
+		fields := make([]string, 0, len(details.FieldErrors))
+		for field := range details.FieldErrors {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			fmt.Fprintf(&sb, "\n- field '%s': %s", field, details.FieldErrors[field])
+		}
+	}
+
+	switch {
+	case info.Code == models.ErrCodeRateLimited && details.RetryAfterSeconds > 0:
+		fmt.Fprintf(&sb, "\nAtlassian is throttling this workspace; retry in %d seconds.", details.RetryAfterSeconds)
+	case info.Code == models.ErrCodeRateLimited:
+		sb.WriteString("\nAtlassian is throttling this workspace; wait before retrying.")
+	case len(details.FieldErrors) > 0:
+		sb.WriteString("\nCorrect the fields listed above and try again.")
+	case errorHints[info.Code] != "":
+		sb.WriteString("\n" + errorHints[info.Code])
+	}
+
+	return sb.String()
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync/atomic"
 
@@ -9,8 +10,6 @@ import (
 	"github.com/providentiaww/trilix-atlassian-mcp/pkg/mcp"
 )
 
-var requestIDCounter int64
-
 // JiraHandler handles Jira-related MCP tool calls
 type JiraHandler struct {
 	callService func(models.JiraRequest) (*models.JiraResponse, error)
@@ -28,7 +27,7 @@ func (h *JiraHandler) ListTools() []mcp.Tool {
 	return []mcp.Tool{
 		{
 			Name:        "jira_list_issues",
-			Description: "Search for Jira issues using JQL. Supports querying multiple workspaces - specify workspace_id to search a specific organization.",
+			Description: "Search for Jira issues using JQL. Supports querying multiple workspaces - specify workspace_id to search a specific organization. When more results exist, the response has a next_cursor to pass as cursor.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -42,15 +41,48 @@ func (h *JiraHandler) ListTools() []mcp.Tool {
 					},
 					"limit": map[string]interface{}{
 						"type":        "number",
-						"description": "Maximum number of results",
+						"description": "Maximum number of results (per call, or in total with fetch_all)",
 						"default":     50,
 					},
+					"cursor": map[string]interface{}{
+						"type":        "string",
+						"description": "next_cursor from a previous call, to continue the search",
+					},
+					"fetch_all": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Walk pages server-side until limit results were gathered (at most 1000)",
+						"default":     false,
+					},
 					"fields": map[string]interface{}{
 						"type": "array",
 						"items": map[string]string{
 							"type": "string",
 						},
-						"description": "Fields to return",
+						"description": "Fields to return (default: the navigable fields, '*navigable'; use '*all' for every field)",
+					},
+					"expand": map[string]interface{}{
+						"type": "array",
+						"items": map[string]string{
+							"type": "string",
+						},
+						"description": "Entities to expand for each issue (e.g., 'renderedFields', 'changelog', 'names')",
+					},
+					"properties": map[string]interface{}{
+						"type": "array",
+						"items": map[string]string{
+							"type": "string",
+						},
+						"description": "Issue properties to return",
+					},
+					"fields_by_keys": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Whether fields lists field keys instead of field IDs",
+						"default":     false,
+					},
+					"count_total": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also return the approximate number of matching issues as total (Cloud needs an extra request for it; Data Center always returns it)",
+						"default":     false,
 					},
 				},
 				"required": []string{"workspace_id", "jql"},
@@ -58,7 +90,7 @@ func (h *JiraHandler) ListTools() []mcp.Tool {
 		},
 		{
 			Name:        "jira_get_issue",
-			Description: "Get a specific issue by key from a workspace. You can query different workspaces in the same chat by specifying different workspace_id values.",
+			Description: "Get a specific issue by key from a workspace. Rich text fields such as the description and comments are returned as Markdown. You can query different workspaces in the same chat by specifying different workspace_id values.",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -105,11 +137,17 @@ func (h *JiraHandler) ListTools() []mcp.Tool {
 					},
 					"description": map[string]interface{}{
 						"type":        "string",
-						"description": "Issue description",
+						"description": "Issue description, in the format given by format",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.TextFormatMarkdown, models.TextFormatPlain},
+						"description": "Format of description: markdown (headings, lists, tables, code blocks and links are converted to Jira rich text) or text (kept verbatim)",
+						"default":     models.TextFormatMarkdown,
 					},
 					"additional_fields": map[string]interface{}{
 						"type":        "object",
-						"description": "Additional fields to set",
+						"description": "Additional fields to set. description and environment given as strings are converted like description",
 					},
 				},
 				"required": []string{"workspace_id", "project_key", "issue_type", "summary"},
@@ -131,7 +169,13 @@ func (h *JiraHandler) ListTools() []mcp.Tool {
 					},
 					"fields": map[string]interface{}{
 						"type":        "object",
-						"description": "Fields to update",
+						"description": "Fields to update. description and environment may be given as strings in the format given by format",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.TextFormatMarkdown, models.TextFormatPlain},
+						"description": "Format of string description and environment fields: markdown or text",
+						"default":     models.TextFormatMarkdown,
 					},
 				},
 				"required": []string{"workspace_id", "issue_key", "fields"},
@@ -153,7 +197,13 @@ func (h *JiraHandler) ListTools() []mcp.Tool {
 					},
 					"body": map[string]interface{}{
 						"type":        "string",
-						"description": "Comment body",
+						"description": "Comment body, in the format given by format",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.TextFormatMarkdown, models.TextFormatPlain},
+						"description": "Format of body: markdown (converted to Jira rich text) or text (kept verbatim)",
+						"default":     models.TextFormatMarkdown,
 					},
 				},
 				"required": []string{"workspace_id", "issue_key", "body"},
@@ -221,10 +271,10 @@ func (h *JiraHandler) HandleTool(call mcp.ToolCall, userID string) (mcp.ToolResu
 		}
 		return mcp.ToolResult{
 			Content: []mcp.ContentBlock{
-				{Type: "text", Text: fmt.Sprintf("Error: %s", errorMsg)},
+				{Type: "text", Text: describeError(resp.Error)},
 			},
 			IsError: true,
-		}, fmt.Errorf(errorMsg)
+		}, errors.New(errorMsg)
 	}
 
 	// Convert response to JSON string
@@ -2,28 +2,80 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
 	"github.com/providentiaww/trilix-atlassian-mcp/pkg/mcp"
 )
 
+// Workspace management errors, mapped to HTTP status codes by the REST endpoint
+var (
+	ErrInvalidWorkspace    = errors.New("invalid workspace")
+	ErrWorkspaceExists     = errors.New("workspace already exists")
+	ErrWorkspaceNotFound   = errors.New("workspace not found")
+	ErrCredentialsRejected = errors.New("credentials rejected")
+	ErrWorkspaceShared     = errors.New("workspace is shared by an organization")
+	ErrNotOrgAdmin         = errors.New("organization admin role required")
+)
+
 // ManagementHandler handles workspace management tools
 type ManagementHandler struct {
-	credStore storage.CredentialStoreInterface
+	credStore      storage.CredentialStoreInterface
+	callJira       func(models.JiraRequest) (*models.JiraResponse, error)
+	callConfluence func(models.ConfluenceRequest) (*models.ConfluenceResponse, error)
+	validateToken  TokenValidator
+}
+
+// TokenValidator authenticates API token credentials with a product and reports who
+// they belong to. It runs inside the MCP server, so tokens that are not saved yet are
+// never sent to the product services.
+type TokenValidator func(creds *models.WorkspaceCredentials, product string) (*models.CredentialCheck, error)
+
+// WorkspaceInput describes a workspace to add, update or validate. Empty fields
+// keep their stored values on update.
+type WorkspaceInput struct {
+	WorkspaceID   string   `json:"workspace_id"`
+	WorkspaceName string   `json:"workspace_name,omitempty"`
+	Site          string   `json:"site,omitempty"`
+	JiraURL       string   `json:"jira_url,omitempty"`
+	ConfluenceURL string   `json:"confluence_url,omitempty"`
+	Products      []string `json:"products,omitempty"`
+	Deployment    string   `json:"deployment,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	APIToken      string   `json:"api_token,omitempty"`
+}
+
+// WorkspaceResult is returned after a workspace is saved or validated
+type WorkspaceResult struct {
+	Workspace  *models.AtlassianCredential `json:"workspace"`
+	Validation []models.CredentialCheck    `json:"validation"`
 }
 
-// NewManagementHandler creates a new management handler
-func NewManagementHandler(credStore storage.CredentialStoreInterface) *ManagementHandler {
+// NewManagementHandler creates a new management handler. API token credentials are
+// checked with validateToken before they are saved; the service callers check stored
+// OAuth 2.0 workspaces and probe workspace health.
+func NewManagementHandler(
+	credStore storage.CredentialStoreInterface,
+	callJira func(models.JiraRequest) (*models.JiraResponse, error),
+	callConfluence func(models.ConfluenceRequest) (*models.ConfluenceResponse, error),
+	validateToken TokenValidator,
+) *ManagementHandler {
 	return &ManagementHandler{
-		credStore: credStore,
+		credStore:      credStore,
+		callJira:       callJira,
+		callConfluence: callConfluence,
+		validateToken:  validateToken,
 	}
 }
 
 // ListTools returns the list of management tools
 func (h *ManagementHandler) ListTools() []mcp.Tool {
-	return []mcp.Tool{
+	tools := []mcp.Tool{
 		{
 			Name:        "list_workspaces",
 			Description: "List all configured Atlassian workspaces. You can connect to multiple workspaces simultaneously and query different organizations in the same chat session.",
@@ -34,7 +86,7 @@ func (h *ManagementHandler) ListTools() []mcp.Tool {
 		},
 		{
 			Name:        "workspace_status",
-			Description: "Check connectivity status of a workspace",
+			Description: "Check live connectivity of a workspace: authentication, account identity, permissions, OAuth scopes, latency and rate-limit headroom for each product",
 			InputSchema: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -46,7 +98,107 @@ func (h *ManagementHandler) ListTools() []mcp.Tool {
 				"required": []string{"workspace_id"},
 			},
 		},
+		{
+			Name:        "add_workspace",
+			Description: "Connect a new Atlassian workspace with an API token (or a Personal Access Token for Data Center). The token is checked against Jira and Confluence before it is saved.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": workspaceInputProperties(),
+				"required":   []string{"workspace_id", "site", "api_token"},
+			},
+		},
+		{
+			Name:        "update_workspace",
+			Description: "Update a workspace's settings or rotate its API token. Omitted fields keep their current values; the resulting credentials are checked before they are saved. Changing site, jira_url or confluence_url requires api_token.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": workspaceInputProperties(),
+				"required":   []string{"workspace_id"},
+			},
+		},
+		{
+			Name:        "remove_workspace",
+			Description: "Remove a workspace and delete its stored credentials",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"workspace_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Workspace ID to remove",
+					},
+				},
+				"required": []string{"workspace_id"},
+			},
+		},
+		{
+			Name:        "validate_workspace",
+			Description: "Check credentials against Jira (/myself) and Confluence (/user/current) without saving anything. Pass site and api_token to check new credentials, or only workspace_id to check the stored ones against their stored URLs.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": workspaceInputProperties(),
+				"required":   []string{"workspace_id"},
+			},
+		},
 	}
+	tools = append(tools, preferenceTools()...)
+	return append(tools, organizationTools()...)
+}
+
+// workspaceInputProperties returns the schema properties shared by the workspace editing tools
+func workspaceInputProperties() map[string]interface{} {
+	return map[string]interface{}{
+		"workspace_id": map[string]interface{}{
+			"type":        "string",
+			"description": "Workspace ID, a short label used to address the workspace (e.g., 'eso', 'providentia')",
+		},
+		"workspace_name": map[string]interface{}{
+			"type":        "string",
+			"description": "Display name",
+		},
+		"site": map[string]interface{}{
+			"type":        "string",
+			"description": "Atlassian site URL (e.g., 'https://eso.atlassian.net')",
+		},
+		"jira_url": map[string]interface{}{
+			"type":        "string",
+			"description": "Jira base URL, when it differs from the site URL",
+		},
+		"confluence_url": map[string]interface{}{
+			"type":        "string",
+			"description": "Confluence base URL, when it differs from the site URL + /wiki",
+		},
+		"products": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "string",
+				"enum": models.AllProducts,
+			},
+			"description": "Enabled products (default: all)",
+		},
+		"deployment": map[string]interface{}{
+			"type":        "string",
+			"enum":        []string{models.DeploymentCloud, models.DeploymentDataCenter},
+			"description": "Deployment type (default: cloud)",
+		},
+		"email": map[string]interface{}{
+			"type":        "string",
+			"description": "Atlassian account email (required for Cloud, unused for Data Center)",
+		},
+		"api_token": map[string]interface{}{
+			"type":        "string",
+			"description": "Atlassian API token, or Personal Access Token for Data Center",
+		},
+	}
+}
+
+// HasTool reports whether a tool name belongs to the management handler
+func (h *ManagementHandler) HasTool(name string) bool {
+	for _, tool := range h.ListTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // HandleTool handles a management tool call
@@ -56,6 +208,15 @@ func (h *ManagementHandler) HandleTool(call mcp.ToolCall, userID string) (mcp.To
 		return h.handleListWorkspaces(userID)
 	case "workspace_status":
 		return h.handleWorkspaceStatus(call, userID)
+	case "add_workspace", "update_workspace", "validate_workspace":
+		return h.handleSaveWorkspace(call, userID)
+	case "remove_workspace":
+		return h.handleRemoveWorkspace(call, userID)
+	case "set_default_workspace", "set_workspace_alias":
+		return h.handlePreferenceTool(call, userID)
+	case "create_organization", "set_organization_member", "remove_organization_member",
+		"share_workspace", "unshare_workspace":
+		return h.handleOrganizationTool(call, userID)
 	default:
 		return mcp.ToolResult{
 			Content: []mcp.ContentBlock{
@@ -67,7 +228,7 @@ func (h *ManagementHandler) HandleTool(call mcp.ToolCall, userID string) (mcp.To
 }
 
 func (h *ManagementHandler) handleListWorkspaces(userID string) (mcp.ToolResult, error) {
-	workspaces, err := h.credStore.ListWorkspaces(userID)
+	workspaces, err := h.ListWorkspaces(userID)
 	if err != nil {
 		return mcp.ToolResult{
 			Content: []mcp.ContentBlock{
@@ -97,7 +258,7 @@ func (h *ManagementHandler) handleWorkspaceStatus(call mcp.ToolCall, userID stri
 		}, fmt.Errorf("workspace_id is required")
 	}
 
-	_, err := h.credStore.GetCredentials(userID, workspaceID)
+	creds, err := h.credStore.GetCredentials(userID, workspaceID)
 	if err != nil {
 		return mcp.ToolResult{
 			Content: []mcp.ContentBlock{
@@ -107,9 +268,14 @@ func (h *ManagementHandler) handleWorkspaceStatus(call mcp.ToolCall, userID stri
 		}, err
 	}
 
+	report := h.probeWorkspace(userID, workspaceID, creds)
+
 	result := map[string]interface{}{
 		"workspace_id": workspaceID,
-		"status":       "connected",
+		"status":       overallHealth(report),
+		"deployment":   creds.DeploymentType(),
+		"auth_type":    creds.AuthKind(),
+		"products":     report,
 	}
 
 	resultJSON, _ := json.MarshalIndent(result, "", "  ")
@@ -121,3 +287,552 @@ func (h *ManagementHandler) handleWorkspaceStatus(call mcp.ToolCall, userID stri
 	}, nil
 }
 
+func (h *ManagementHandler) handleSaveWorkspace(call mcp.ToolCall, userID string) (mcp.ToolResult, error) {
+	var input WorkspaceInput
+	argsJSON, _ := json.Marshal(call.Arguments)
+	if err := json.Unmarshal(argsJSON, &input); err != nil {
+		return mcp.ToolResult{
+			Content: []mcp.ContentBlock{
+				{Type: "text", Text: fmt.Sprintf("Error: invalid arguments: %v", err)},
+			},
+			IsError: true,
+		}, err
+	}
+
+	var result *WorkspaceResult
+	var err error
+	switch call.Name {
+	case "add_workspace":
+		result, err = h.AddWorkspace(userID, input)
+	case "update_workspace":
+		result, err = h.UpdateWorkspace(userID, input)
+	default:
+		result, err = h.ValidateWorkspace(userID, input)
+	}
+	if err != nil {
+		return mcp.ToolResult{
+			Content: []mcp.ContentBlock{
+				{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, err
+	}
+
+	resultJSON, _ := json.MarshalIndent(result, "", "  ")
+
+	return mcp.ToolResult{
+		Content: []mcp.ContentBlock{
+			{Type: "text", Text: string(resultJSON)},
+		},
+	}, nil
+}
+
+func (h *ManagementHandler) handleRemoveWorkspace(call mcp.ToolCall, userID string) (mcp.ToolResult, error) {
+	workspaceID, _ := call.Arguments["workspace_id"].(string)
+	if err := h.RemoveWorkspace(userID, workspaceID); err != nil {
+		return mcp.ToolResult{
+			Content: []mcp.ContentBlock{
+				{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, err
+	}
+
+	return mcp.ToolResult{
+		Content: []mcp.ContentBlock{
+			{Type: "text", Text: fmt.Sprintf("Workspace %s removed", workspaceID)},
+		},
+	}, nil
+}
+
+// ListWorkspaces returns the user's workspaces with their effective per-product URLs and enabled products
+func (h *ManagementHandler) ListWorkspaces(userID string) ([]models.AtlassianCredential, error) {
+	workspaces, err := h.credStore.ListWorkspaces(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Preferences are informational here; stores without them simply report none
+	var prefs *models.WorkspacePreferences
+	if prefsStore, err := storage.Preferences(h.credStore); err == nil {
+		prefs, _ = prefsStore.GetPreferences(userID)
+	}
+
+	for i := range workspaces {
+		ws := &workspaces[i]
+		describeWorkspace(ws)
+		if prefs != nil {
+			ws.Default = ws.WorkspaceID == prefs.DefaultWorkspace
+			ws.Aliases = prefs.AliasesFor(ws.WorkspaceID)
+		}
+	}
+
+	return workspaces, nil
+}
+
+// AddWorkspace validates and saves a new workspace
+func (h *ManagementHandler) AddWorkspace(userID string, input WorkspaceInput) (*WorkspaceResult, error) {
+	cred, err := newWorkspaceCredential(userID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := h.findWorkspace(userID, cred.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	// A personal workspace may override one shared by an organization
+	if existing != nil && existing.Source != models.WorkspaceSourceOrganization {
+		return nil, fmt.Errorf("%w: %s (use update_workspace to change it)", ErrWorkspaceExists, cred.WorkspaceID)
+	}
+
+	return h.validateAndSave(cred, true)
+}
+
+// UpdateWorkspace merges changes into a stored workspace, validates the result and saves it.
+// Supplying api_token rotates the token, and switches OAuth 2.0 workspaces to token auth.
+func (h *ManagementHandler) UpdateWorkspace(userID string, input WorkspaceInput) (*WorkspaceResult, error) {
+	cred, err := h.mergeWorkspace(userID, input, true)
+	if err != nil {
+		return nil, err
+	}
+	return h.validateAndSave(cred, true)
+}
+
+// ValidateWorkspace checks credentials without saving them: the supplied ones when
+// api_token is set, otherwise the stored workspace merged with any supplied changes.
+// Stored tokens are only checked against their stored URLs.
+func (h *ManagementHandler) ValidateWorkspace(userID string, input WorkspaceInput) (*WorkspaceResult, error) {
+	var cred *models.AtlassianCredential
+	var err error
+	if input.APIToken != "" && input.Site != "" {
+		cred, err = newWorkspaceCredential(userID, input)
+	} else {
+		cred, err = h.mergeWorkspace(userID, input, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h.validateAndSave(cred, false)
+}
+
+// RemoveWorkspace deletes a workspace and its credentials
+func (h *ManagementHandler) RemoveWorkspace(userID, workspaceID string) error {
+	workspaceID = strings.TrimSpace(workspaceID)
+	if workspaceID == "" {
+		return fmt.Errorf("%w: workspace_id is required", ErrInvalidWorkspace)
+	}
+
+	existing, err := h.findWorkspace(userID, workspaceID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("%w: %s", ErrWorkspaceNotFound, workspaceID)
+	}
+	if existing.Source == models.WorkspaceSourceOrganization {
+		return fmt.Errorf("%w: %s belongs to organization %s; ask an admin to unshare it",
+			ErrWorkspaceShared, workspaceID, existing.OrganizationID)
+	}
+
+	if err := h.credStore.DeleteCredentials(userID, workspaceID); err != nil {
+		return err
+	}
+
+	// Drop aliases and the default unless a shared workspace with the same ID remains
+	if remaining, err := h.findWorkspace(userID, workspaceID); err == nil && remaining == nil {
+		h.forgetWorkspace(userID, workspaceID)
+	}
+	return nil
+}
+
+// newWorkspaceCredential builds API token credentials for a workspace that is not stored yet
+func newWorkspaceCredential(userID string, input WorkspaceInput) (*models.AtlassianCredential, error) {
+	cred := &models.AtlassianCredential{
+		UserID:        userID,
+		WorkspaceID:   strings.TrimSpace(input.WorkspaceID),
+		WorkspaceName: strings.TrimSpace(input.WorkspaceName),
+		AtlassianURL:  strings.TrimSuffix(strings.TrimSpace(input.Site), "/"),
+		JiraURL:       strings.TrimSpace(input.JiraURL),
+		ConfluenceURL: strings.TrimSpace(input.ConfluenceURL),
+		Products:      input.Products,
+		Deployment:    input.Deployment,
+		AuthType:      models.AuthTypeAPIToken,
+		Email:         strings.TrimSpace(input.Email),
+		APIToken:      strings.TrimSpace(input.APIToken),
+	}
+	if cred.WorkspaceName == "" {
+		cred.WorkspaceName = cred.WorkspaceID
+	}
+
+	if err := checkWorkspace(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// mergeWorkspace applies the supplied fields on top of a stored workspace. Shared
+// workspaces can only be updated by overriding them with a personal token.
+func (h *ManagementHandler) mergeWorkspace(userID string, input WorkspaceInput, update bool) (*models.AtlassianCredential, error) {
+	workspaceID := strings.TrimSpace(input.WorkspaceID)
+	if workspaceID == "" {
+		return nil, fmt.Errorf("%w: workspace_id is required", ErrInvalidWorkspace)
+	}
+
+	cred, err := h.findWorkspace(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if cred == nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, workspaceID)
+	}
+
+	// A stored token is only sent to the URLs it was stored with: anyone who could point
+	// it at another host, e.g. a member of the organization sharing it, could capture it
+	newToken := strings.TrimSpace(input.APIToken) != ""
+	if !newToken && (urlChanged(input.Site, cred.AtlassianURL) || urlChanged(input.JiraURL, cred.JiraURL) ||
+		urlChanged(input.ConfluenceURL, cred.ConfluenceURL)) {
+		return nil, fmt.Errorf("%w: changing site, jira_url or confluence_url requires api_token", ErrInvalidWorkspace)
+	}
+
+	// Supplying a token for a shared workspace creates a personal override
+	shared := cred.Source == models.WorkspaceSourceOrganization && update
+	if shared && !newToken {
+		return nil, fmt.Errorf("%w: %s belongs to organization %s; supply api_token to override it with your own credentials",
+			ErrWorkspaceShared, workspaceID, cred.OrganizationID)
+	}
+
+	stored, err := h.credStore.GetCredentials(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	cred.UserID = userID
+	cred.APIToken = stored.Token
+	cred.OAuth = stored.OAuth
+	cred.CloudID = stored.CloudID
+	cred.AuthType = stored.AuthKind()
+	if shared {
+		cred.Source = models.WorkspaceSourcePersonal
+		cred.Overrides = cred.OrganizationID
+		cred.OrganizationID = ""
+	}
+
+	if v := strings.TrimSpace(input.WorkspaceName); v != "" {
+		cred.WorkspaceName = v
+	}
+	if v := strings.TrimSpace(input.Site); v != "" {
+		cred.AtlassianURL = strings.TrimSuffix(v, "/")
+	}
+	if v := strings.TrimSpace(input.JiraURL); v != "" {
+		cred.JiraURL = v
+	}
+	if v := strings.TrimSpace(input.ConfluenceURL); v != "" {
+		cred.ConfluenceURL = v
+	}
+	if input.Products != nil {
+		cred.Products = input.Products
+	}
+	if v := strings.TrimSpace(input.Deployment); v != "" {
+		cred.Deployment = v
+	}
+	if v := strings.TrimSpace(input.Email); v != "" {
+		cred.Email = v
+	}
+	if newToken {
+		cred.APIToken = strings.TrimSpace(input.APIToken)
+		cred.AuthType = models.AuthTypeAPIToken
+		cred.OAuth = nil
+		cred.CloudID = ""
+	}
+
+	if err := checkWorkspace(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// urlChanged reports whether a supplied URL differs from the stored one; empty means unchanged
+func urlChanged(supplied, stored string) bool {
+	supplied = strings.TrimSuffix(strings.TrimSpace(supplied), "/")
+	return supplied != "" && supplied != strings.TrimSuffix(stored, "/")
+}
+
+// checkWorkspace rejects credentials that cannot work before any network call is made
+func checkWorkspace(cred *models.AtlassianCredential) error {
+	if cred.WorkspaceID == "" {
+		return fmt.Errorf("%w: workspace_id is required", ErrInvalidWorkspace)
+	}
+	for i, p := range cred.Products {
+		product := strings.ToLower(strings.TrimSpace(p))
+		if product != models.ProductJira && product != models.ProductConfluence {
+			return fmt.Errorf("%w: unknown product %q", ErrInvalidWorkspace, p)
+		}
+		cred.Products[i] = product
+	}
+	if len(cred.EnabledProducts()) == 0 {
+		return fmt.Errorf("%w: at least one product must be enabled", ErrInvalidWorkspace)
+	}
+	if cred.AuthKind() == models.AuthTypeOAuth2 {
+		return nil
+	}
+	if cred.AtlassianURL == "" {
+		return fmt.Errorf("%w: site is required", ErrInvalidWorkspace)
+	}
+	if !strings.HasPrefix(cred.AtlassianURL, "https://") && !strings.HasPrefix(cred.AtlassianURL, "http://") {
+		return fmt.Errorf("%w: site must be an http(s) URL", ErrInvalidWorkspace)
+	}
+	if cred.APIToken == "" {
+		return fmt.Errorf("%w: api_token is required", ErrInvalidWorkspace)
+	}
+	if cred.DeploymentType() == models.DeploymentCloud && cred.Email == "" {
+		return fmt.Errorf("%w: email is required for Atlassian Cloud", ErrInvalidWorkspace)
+	}
+	return nil
+}
+
+// findWorkspace returns the listed workspace with the given ID, or nil if there is none
+func (h *ManagementHandler) findWorkspace(userID, workspaceID string) (*models.AtlassianCredential, error) {
+	workspaces, err := h.credStore.ListWorkspaces(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range workspaces {
+		if workspaces[i].WorkspaceID == workspaceID {
+			return &workspaces[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// validateAndSave checks the credentials against every enabled product and saves them if asked to
+func (h *ManagementHandler) validateAndSave(cred *models.AtlassianCredential, save bool) (*WorkspaceResult, error) {
+	var checks []models.CredentialCheck
+	for _, product := range cred.EnabledProducts() {
+		check, err := h.validateProduct(cred, product)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, *check)
+	}
+
+	if save {
+		if err := h.credStore.SaveCredentials(cred); err != nil {
+			return nil, fmt.Errorf("failed to save workspace %s: %w", cred.WorkspaceID, err)
+		}
+	}
+
+	listed := *cred
+	listed.APIToken = ""
+	listed.OAuth = nil
+	describeWorkspace(&listed)
+
+	return &WorkspaceResult{Workspace: &listed, Validation: checks}, nil
+}
+
+// validateProduct authenticates the credentials with a product. API token credentials
+// are checked in-process; OAuth 2.0 workspaces are checked by the product service with
+// their stored tokens, so no secret is ever part of a service request.
+func (h *ManagementHandler) validateProduct(cred *models.AtlassianCredential, product string) (*models.CredentialCheck, error) {
+	if cred.AuthKind() == models.AuthTypeAPIToken {
+		check, err := h.validateToken(workspaceCredentials(cred), product)
+		if err != nil {
+			return nil, fmt.Errorf("%w by %s: %v", ErrCredentialsRejected, product, err)
+		}
+		return check, nil
+	}
+
+	requestID := fmt.Sprintf("req_%d", atomic.AddInt64(&requestIDCounter, 1))
+
+	var success bool
+	var data any
+	var errInfo *models.ErrorInfo
+	switch product {
+	case models.ProductJira:
+		resp, err := h.callJira(models.JiraRequest{
+			Action:      "validate_credentials",
+			WorkspaceID: cred.WorkspaceID,
+			UserID:      cred.UserID,
+			RequestID:   requestID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to reach the Jira service: %w", err)
+		}
+		success, data, errInfo = resp.Success, resp.Data, resp.Error
+	case models.ProductConfluence:
+		resp, err := h.callConfluence(models.ConfluenceRequest{
+			Action:      "validate_credentials",
+			WorkspaceID: cred.WorkspaceID,
+			UserID:      cred.UserID,
+			RequestID:   requestID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to reach the Confluence service: %w", err)
+		}
+		success, data, errInfo = resp.Success, resp.Data, resp.Error
+	default:
+		return nil, fmt.Errorf("%w: unknown product %q", ErrInvalidWorkspace, product)
+	}
+
+	if !success {
+		message := "unknown error"
+		if errInfo != nil {
+			message = errInfo.Message
+		}
+		return nil, fmt.Errorf("%w by %s: %s", ErrCredentialsRejected, product, message)
+	}
+
+	var check models.CredentialCheck
+	dataJSON, _ := json.Marshal(data)
+	if err := json.Unmarshal(dataJSON, &check); err != nil {
+		return nil, fmt.Errorf("invalid %s validation response: %w", product, err)
+	}
+	return &check, nil
+}
+
+// workspaceCredentials returns API token credentials in the form the API clients take
+func workspaceCredentials(cred *models.AtlassianCredential) *models.WorkspaceCredentials {
+	return &models.WorkspaceCredentials{
+		Site:          cred.AtlassianURL,
+		JiraURL:       cred.JiraURL,
+		ConfluenceURL: cred.ConfluenceURL,
+		Products:      cred.Products,
+		Deployment:    cred.Deployment,
+		AuthType:      models.AuthTypeAPIToken,
+		Email:         cred.Email,
+		Token:         cred.APIToken,
+	}
+}
+
+// probeWorkspace runs live health checks against every product of a workspace in parallel
+func (h *ManagementHandler) probeWorkspace(userID, workspaceID string, creds *models.WorkspaceCredentials) map[string]*models.ProductHealth {
+	report := make(map[string]*models.ProductHealth, len(models.AllProducts))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, product := range models.AllProducts {
+		if !creds.HasProduct(product) {
+			report[product] = &models.ProductHealth{Product: product, Status: models.HealthDisabled}
+			continue
+		}
+
+		wg.Add(1)
+		go func(product string) {
+			defer wg.Done()
+			health := h.probeProduct(userID, workspaceID, product)
+			mu.Lock()
+			report[product] = health
+			mu.Unlock()
+		}(product)
+	}
+
+	wg.Wait()
+	return report
+}
+
+// probeProduct asks a product service for a health check
+func (h *ManagementHandler) probeProduct(userID, workspaceID, product string) *models.ProductHealth {
+	requestID := fmt.Sprintf("req_%d", atomic.AddInt64(&requestIDCounter, 1))
+
+	var success bool
+	var data any
+	var errInfo *models.ErrorInfo
+	var err error
+	switch product {
+	case models.ProductJira:
+		var resp *models.JiraResponse
+		resp, err = h.callJira(models.JiraRequest{
+			Action:      "health_check",
+			WorkspaceID: workspaceID,
+			UserID:      userID,
+			RequestID:   requestID,
+		})
+		if err == nil {
+			success, data, errInfo = resp.Success, resp.Data, resp.Error
+		}
+	case models.ProductConfluence:
+		var resp *models.ConfluenceResponse
+		resp, err = h.callConfluence(models.ConfluenceRequest{
+			Action:      "health_check",
+			WorkspaceID: workspaceID,
+			UserID:      userID,
+			RequestID:   requestID,
+		})
+		if err == nil {
+			success, data, errInfo = resp.Success, resp.Data, resp.Error
+		}
+	}
+
+	if err != nil {
+		return &models.ProductHealth{
+			Product: product,
+			Status:  models.HealthUnreachable,
+			Error:   fmt.Sprintf("%s service did not respond: %v", product, err),
+		}
+	}
+	if !success {
+		health := &models.ProductHealth{Product: product, Status: models.HealthUnavailable}
+		if errInfo != nil {
+			health.Error = errInfo.Message
+			switch errInfo.Code {
+			case models.ErrCodeAuthFailed:
+				health.Status = models.HealthAuthFailed
+			case models.ErrCodeTimeout, models.ErrCodeUnavailable:
+				health.Status = models.HealthUnreachable
+			}
+		}
+		return health
+	}
+
+	var health models.ProductHealth
+	dataJSON, _ := json.Marshal(data)
+	if err := json.Unmarshal(dataJSON, &health); err != nil {
+		return &models.ProductHealth{
+			Product: product,
+			Status:  models.HealthUnavailable,
+			Error:   fmt.Sprintf("invalid health response: %v", err),
+		}
+	}
+	return &health
+}
+
+// overallHealth summarizes a report: healthy when every enabled product is ok,
+// unhealthy when none is usable, degraded otherwise
+func overallHealth(report map[string]*models.ProductHealth) string {
+	enabled, ok, usable := 0, 0, 0
+	for _, health := range report {
+		if health.Status == models.HealthDisabled {
+			continue
+		}
+		enabled++
+		switch health.Status {
+		case models.HealthOK:
+			ok++
+			usable++
+		case models.HealthDegraded:
+			usable++
+		}
+	}
+
+	switch {
+	case enabled > 0 && ok == enabled:
+		return "healthy"
+	case usable == 0:
+		return "unhealthy"
+	default:
+		return "degraded"
+	}
+}
+
+// describeWorkspace fills in the effective per-product URLs and enabled products
+func describeWorkspace(ws *models.AtlassianCredential) {
+	ws.Products = ws.EnabledProducts()
+	ws.Deployment = ws.DeploymentType()
+	ws.JiraURL = ""
+	ws.ConfluenceURL = ""
+	if ws.HasProduct(models.ProductJira) {
+		ws.JiraURL = ws.ProductURL(models.ProductJira)
+	}
+	if ws.HasProduct(models.ProductConfluence) {
+		ws.ConfluenceURL = ws.ProductURL(models.ProductConfluence)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// RegisterRoutes exposes workspace management as a REST endpoint:
+//
+//	GET    /api/workspaces                  list workspaces
+//	POST   /api/workspaces                  add a workspace (WorkspaceInput body)
+//	PUT    /api/workspaces                  update or rotate a workspace (WorkspaceInput body)
+//	DELETE /api/workspaces?workspace_id=ID  remove a workspace
+//
+// userID authenticates the caller of each request. The routes hold credentials, so
+// they are refused without one. POST and PUT bodies must be sent as application/json,
+// which cross-site forms cannot do.
+func (h *ManagementHandler) RegisterRoutes(mux *http.ServeMux, userID func(*http.Request) (string, error)) error {
+	if userID == nil {
+		return errors.New("workspace management routes require an authentication provider")
+	}
+
+	mux.HandleFunc("/api/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		uid, err := userID(r)
+		if err != nil {
+			writeJSONError(w, http.StatusUnauthorized, err)
+			return
+		}
+		if uid == "" {
+			writeJSONError(w, http.StatusUnauthorized, errors.New("unauthenticated request"))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			workspaces, err := h.ListWorkspaces(uid)
+			if err != nil {
+				writeJSONError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if workspaces == nil {
+				workspaces = []models.AtlassianCredential{}
+			}
+			writeJSON(w, http.StatusOK, workspaces)
+
+		case http.MethodPost, http.MethodPut:
+			if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+				writeJSONError(w, http.StatusUnsupportedMediaType, errors.New("Content-Type must be application/json"))
+				return
+			}
+
+			var input WorkspaceInput
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&input); err != nil {
+				writeJSONError(w, http.StatusBadRequest, err)
+				return
+			}
+
+			var result *WorkspaceResult
+			status := http.StatusOK
+			if r.Method == http.MethodPost {
+				result, err = h.AddWorkspace(uid, input)
+				status = http.StatusCreated
+			} else {
+				result, err = h.UpdateWorkspace(uid, input)
+			}
+			if err != nil {
+				writeJSONError(w, workspaceErrorStatus(err), err)
+				return
+			}
+			writeJSON(w, status, result)
+
+		case http.MethodDelete:
+			if err := h.RemoveWorkspace(uid, r.URL.Query().Get("workspace_id")); err != nil {
+				writeJSONError(w, workspaceErrorStatus(err), err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT, DELETE")
+			writeJSONError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		}
+	})
+
+	return nil
+}
+
+// workspaceErrorStatus maps workspace management errors to HTTP status codes
+func workspaceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidWorkspace):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrWorkspaceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrWorkspaceExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrWorkspaceShared), errors.Is(err, ErrNotOrgAdmin):
+		return http.StatusForbidden
+	case errors.Is(err, ErrCredentialsRejected):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeJSON writes a JSON response body
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes an error as {"error": "..."}
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
+	"github.com/providentiaww/trilix-atlassian-mcp/pkg/mcp"
+)
+
+// organizationTools returns the tools for managing organizations and shared workspaces
+func organizationTools() []mcp.Tool {
+	orgID := map[string]interface{}{
+		"type":        "string",
+		"description": "Organization ID",
+	}
+	workspaceID := map[string]interface{}{
+		"type":        "string",
+		"description": "Workspace ID",
+	}
+
+	return []mcp.Tool{
+		{
+			Name:        "create_organization",
+			Description: "Create an organization (team) to share workspaces with. You become its first admin.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"org_id": orgID,
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Display name",
+					},
+				},
+				"required": []string{"org_id"},
+			},
+		},
+		{
+			Name:        "set_organization_member",
+			Description: "Add a member to an organization or change their role (admins only)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"org_id": orgID,
+					"user_id": map[string]interface{}{
+						"type":        "string",
+						"description": "User ID of the member",
+					},
+					"role": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{models.OrgRoleMember, models.OrgRoleAdmin},
+						"description": "Role (default: member)",
+					},
+				},
+				"required": []string{"org_id", "user_id"},
+			},
+		},
+		{
+			Name:        "remove_organization_member",
+			Description: "Remove a member from an organization, withdrawing their access to shared workspaces (admins only)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"org_id": orgID,
+					"user_id": map[string]interface{}{
+						"type":        "string",
+						"description": "User ID of the member",
+					},
+				},
+				"required": []string{"org_id", "user_id"},
+			},
+		},
+		{
+			Name:        "share_workspace",
+			Description: "Register one of your workspaces with an organization and grant it to members (admins only). Members see it in list_workspaces; a member's own workspace with the same ID overrides it.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"org_id":       orgID,
+					"workspace_id": workspaceID,
+					"user_ids": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+						"description": "Members to grant the workspace to (default: every member)",
+					},
+				},
+				"required": []string{"org_id", "workspace_id"},
+			},
+		},
+		{
+			Name:        "unshare_workspace",
+			Description: "Withdraw a shared workspace from one member, or delete it from the organization entirely when user_id is omitted (admins only)",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"org_id":       orgID,
+					"workspace_id": workspaceID,
+					"user_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Member to withdraw the workspace from",
+					},
+				},
+				"required": []string{"org_id", "workspace_id"},
+			},
+		},
+	}
+}
+
+// handleOrganizationTool runs an organization tool
+func (h *ManagementHandler) handleOrganizationTool(call mcp.ToolCall, userID string) (mcp.ToolResult, error) {
+	str := func(key string) string {
+		v, _ := call.Arguments[key].(string)
+		return strings.TrimSpace(v)
+	}
+
+	var message string
+	var err error
+	switch call.Name {
+	case "create_organization":
+		err = h.CreateOrganization(userID, str("org_id"), str("name"))
+		message = fmt.Sprintf("Organization %s created", str("org_id"))
+	case "set_organization_member":
+		role := str("role")
+		if role == "" {
+			role = models.OrgRoleMember
+		}
+		err = h.SetOrganizationMember(userID, str("org_id"), str("user_id"), role)
+		message = fmt.Sprintf("%s is now %s of %s", str("user_id"), role, str("org_id"))
+	case "remove_organization_member":
+		err = h.RemoveOrganizationMember(userID, str("org_id"), str("user_id"))
+		message = fmt.Sprintf("%s removed from %s", str("user_id"), str("org_id"))
+	case "share_workspace":
+		var userIDs []string
+		if ids, ok := call.Arguments["user_ids"].([]interface{}); ok {
+			for _, id := range ids {
+				if s, ok := id.(string); ok && s != "" {
+					userIDs = append(userIDs, s)
+				}
+			}
+		}
+		err = h.ShareWorkspace(userID, str("org_id"), str("workspace_id"), userIDs)
+		message = fmt.Sprintf("Workspace %s shared with %s", str("workspace_id"), str("org_id"))
+	case "unshare_workspace":
+		err = h.UnshareWorkspace(userID, str("org_id"), str("workspace_id"), str("user_id"))
+		message = fmt.Sprintf("Workspace %s unshared", str("workspace_id"))
+	default:
+		err = fmt.Errorf("unknown tool: %s", call.Name)
+	}
+
+	if err != nil {
+		return mcp.ToolResult{
+			Content: []mcp.ContentBlock{
+				{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, err
+	}
+
+	resultJSON, _ := json.MarshalIndent(map[string]interface{}{"message": message}, "", "  ")
+
+	return mcp.ToolResult{
+		Content: []mcp.ContentBlock{
+			{Type: "text", Text: string(resultJSON)},
+		},
+	}, nil
+}
+
+// CreateOrganization creates an organization with the caller as admin
+func (h *ManagementHandler) CreateOrganization(userID, orgID, name string) error {
+	if orgID == "" {
+		return fmt.Errorf("%w: org_id is required", ErrInvalidWorkspace)
+	}
+	if name == "" {
+		name = orgID
+	}
+
+	orgs, err := storage.Organizations(h.credStore)
+	if err != nil {
+		return err
+	}
+	return orgs.CreateOrganization(orgID, name, userID)
+}
+
+// SetOrganizationMember adds a member or changes their role
+func (h *ManagementHandler) SetOrganizationMember(userID, orgID, memberID, role string) error {
+	if memberID == "" {
+		return fmt.Errorf("%w: user_id is required", ErrInvalidWorkspace)
+	}
+
+	orgs, err := h.adminOrganizations(userID, orgID)
+	if err != nil {
+		return err
+	}
+	return orgs.SetMemberRole(orgID, memberID, role)
+}
+
+// RemoveOrganizationMember removes a member and their grants
+func (h *ManagementHandler) RemoveOrganizationMember(userID, orgID, memberID string) error {
+	if memberID == "" {
+		return fmt.Errorf("%w: user_id is required", ErrInvalidWorkspace)
+	}
+
+	orgs, err := h.adminOrganizations(userID, orgID)
+	if err != nil {
+		return err
+	}
+	return orgs.RemoveMember(orgID, memberID)
+}
+
+// ShareWorkspace copies the caller's workspace into the organization and grants it
+// to the given members, or to every member when userIDs is empty
+func (h *ManagementHandler) ShareWorkspace(userID, orgID, workspaceID string, userIDs []string) error {
+	orgs, err := h.adminOrganizations(userID, orgID)
+	if err != nil {
+		return err
+	}
+
+	cred, err := h.mergeWorkspace(userID, WorkspaceInput{WorkspaceID: workspaceID}, false)
+	if err != nil {
+		return err
+	}
+	if cred.Source == models.WorkspaceSourceOrganization {
+		return fmt.Errorf("%w: %s is already shared by organization %s", ErrWorkspaceShared, workspaceID, cred.OrganizationID)
+	}
+
+	if err := orgs.SaveSharedCredentials(orgID, cred); err != nil {
+		return fmt.Errorf("failed to share workspace %s: %w", workspaceID, err)
+	}
+
+	if len(userIDs) == 0 {
+		return orgs.GrantWorkspace(orgID, workspaceID, "")
+	}
+	for _, memberID := range userIDs {
+		if err := orgs.GrantWorkspace(orgID, workspaceID, memberID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnshareWorkspace withdraws a shared workspace from one member, or deletes it from
+// the organization when memberID is empty
+func (h *ManagementHandler) UnshareWorkspace(userID, orgID, workspaceID, memberID string) error {
+	if workspaceID == "" {
+		return fmt.Errorf("%w: workspace_id is required", ErrInvalidWorkspace)
+	}
+
+	orgs, err := h.adminOrganizations(userID, orgID)
+	if err != nil {
+		return err
+	}
+
+	if memberID == "" {
+		return orgs.DeleteSharedCredentials(orgID, workspaceID)
+	}
+	return orgs.RevokeWorkspace(orgID, workspaceID, memberID)
+}
+
+// adminOrganizations returns the organization store after checking the caller is an admin of orgID
+func (h *ManagementHandler) adminOrganizations(userID, orgID string) (storage.OrganizationStore, error) {
+	if orgID == "" {
+		return nil, fmt.Errorf("%w: org_id is required", ErrInvalidWorkspace)
+	}
+
+	orgs, err := storage.Organizations(h.credStore)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := orgs.MemberRole(orgID, userID)
+	if err == storage.ErrNotFound || (err == nil && role != models.OrgRoleAdmin) {
+		return nil, fmt.Errorf("%w: %s", ErrNotOrgAdmin, orgID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return orgs, nil
+}
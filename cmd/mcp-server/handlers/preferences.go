@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
+	"github.com/providentiaww/trilix-atlassian-mcp/pkg/mcp"
+)
+
+// preferenceTools returns the tools for managing the default workspace and aliases
+func preferenceTools() []mcp.Tool {
+	return []mcp.Tool{
+		{
+			Name:        "set_default_workspace",
+			Description: "Set the workspace used when a Jira or Confluence tool call omits workspace_id. Omit workspace_id to clear the default.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"workspace_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Workspace ID or alias to use by default",
+					},
+				},
+			},
+		},
+		{
+			Name:        "set_workspace_alias",
+			Description: "Create an alternative name for a workspace (e.g., 'eso' for 'eso-prod') that can be used wherever a workspace_id is expected. Omit workspace_id to remove the alias.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"alias": map[string]interface{}{
+						"type":        "string",
+						"description": "Alias to create or remove",
+					},
+					"workspace_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Workspace ID the alias points to",
+					},
+				},
+				"required": []string{"alias"},
+			},
+		},
+	}
+}
+
+// handlePreferenceTool runs a preference tool
+func (h *ManagementHandler) handlePreferenceTool(call mcp.ToolCall, userID string) (mcp.ToolResult, error) {
+	str := func(key string) string {
+		v, _ := call.Arguments[key].(string)
+		return strings.TrimSpace(v)
+	}
+
+	var message string
+	var err error
+	switch call.Name {
+	case "set_default_workspace":
+		var workspaceID string
+		workspaceID, err = h.SetDefaultWorkspace(userID, str("workspace_id"))
+		message = "Default workspace cleared"
+		if workspaceID != "" {
+			message = fmt.Sprintf("Default workspace set to %s", workspaceID)
+		}
+	case "set_workspace_alias":
+		err = h.SetWorkspaceAlias(userID, str("alias"), str("workspace_id"))
+		message = fmt.Sprintf("Alias %s removed", str("alias"))
+		if str("workspace_id") != "" {
+			message = fmt.Sprintf("Alias %s now points to %s", str("alias"), str("workspace_id"))
+		}
+	default:
+		err = fmt.Errorf("unknown tool: %s", call.Name)
+	}
+
+	if err != nil {
+		return mcp.ToolResult{
+			Content: []mcp.ContentBlock{
+				{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+			},
+			IsError: true,
+		}, err
+	}
+
+	resultJSON, _ := json.MarshalIndent(map[string]interface{}{"message": message}, "", "  ")
+
+	return mcp.ToolResult{
+		Content: []mcp.ContentBlock{
+			{Type: "text", Text: string(resultJSON)},
+		},
+	}, nil
+}
+
+// SetDefaultWorkspace sets the user's default workspace, accepting an alias, and returns
+// the workspace ID it resolved to. An empty workspaceID clears the default.
+func (h *ManagementHandler) SetDefaultWorkspace(userID, workspaceID string) (string, error) {
+	prefsStore, err := storage.Preferences(h.credStore)
+	if err != nil {
+		return "", err
+	}
+
+	if workspaceID != "" {
+		prefs, err := prefsStore.GetPreferences(userID)
+		if err != nil {
+			return "", err
+		}
+		workspaceID = prefs.Resolve(workspaceID)
+
+		existing, err := h.findWorkspace(userID, workspaceID)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return "", fmt.Errorf("%w: %s", ErrWorkspaceNotFound, workspaceID)
+		}
+	}
+
+	return workspaceID, prefsStore.SetDefaultWorkspace(userID, workspaceID)
+}
+
+// SetWorkspaceAlias points an alias at a workspace, or removes it when workspaceID is empty.
+// Aliases may not shadow a real workspace ID.
+func (h *ManagementHandler) SetWorkspaceAlias(userID, alias, workspaceID string) error {
+	if alias == "" {
+		return fmt.Errorf("%w: alias is required", ErrInvalidWorkspace)
+	}
+
+	prefsStore, err := storage.Preferences(h.credStore)
+	if err != nil {
+		return err
+	}
+
+	if workspaceID != "" {
+		shadowed, err := h.findWorkspace(userID, alias)
+		if err != nil {
+			return err
+		}
+		if shadowed != nil {
+			return fmt.Errorf("%w: %s is already a workspace ID", ErrInvalidWorkspace, alias)
+		}
+
+		existing, err := h.findWorkspace(userID, workspaceID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return fmt.Errorf("%w: %s", ErrWorkspaceNotFound, workspaceID)
+		}
+	}
+
+	return prefsStore.SetWorkspaceAlias(userID, alias, workspaceID)
+}
+
+// forgetWorkspace drops the aliases and default that point at a removed workspace
+func (h *ManagementHandler) forgetWorkspace(userID, workspaceID string) {
+	prefsStore, err := storage.Preferences(h.credStore)
+	if err != nil {
+		return
+	}
+	prefs, err := prefsStore.GetPreferences(userID)
+	if err != nil {
+		return
+	}
+
+	if prefs.DefaultWorkspace == workspaceID {
+		prefsStore.SetDefaultWorkspace(userID, "")
+	}
+	for _, alias := range prefs.AliasesFor(workspaceID) {
+		prefsStore.SetWorkspaceAlias(userID, alias, "")
+	}
+}
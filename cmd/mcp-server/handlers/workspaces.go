@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
+	"github.com/providentiaww/trilix-atlassian-mcp/pkg/mcp"
+)
+
+// WorkspaceResolver applies a user's workspace aliases and default workspace to tool
+// calls before they are dispatched, so agents do not have to guess workspace IDs
+type WorkspaceResolver struct {
+	credStore storage.CredentialStoreInterface
+}
+
+// NewWorkspaceResolver creates a resolver backed by the credential store's preferences
+func NewWorkspaceResolver(credStore storage.CredentialStoreInterface) *WorkspaceResolver {
+	return &WorkspaceResolver{
+		credStore: credStore,
+	}
+}
+
+// preferences loads the user's preferences; stores without preference support resolve nothing
+func (r *WorkspaceResolver) preferences(userID string) (*models.WorkspacePreferences, error) {
+	prefsStore, err := storage.Preferences(r.credStore)
+	if err != nil {
+		return nil, nil
+	}
+	return prefsStore.GetPreferences(userID)
+}
+
+// Resolve rewrites the workspace arguments of a Jira, Confluence or workspace_status call in
+// place: aliases become workspace IDs and a missing workspace_id becomes the default workspace.
+// A copy's missing dst_workspace becomes its source workspace, never the default, so an
+// omitted target cannot send the copy to another site.
+func (r *WorkspaceResolver) Resolve(call *mcp.ToolCall, userID string) error {
+	if !usesWorkspace(call.Name) {
+		return nil
+	}
+	if call.Arguments == nil {
+		call.Arguments = map[string]interface{}{}
+	}
+
+	prefs, err := r.preferences(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load workspace preferences: %w", err)
+	}
+
+	if call.Name == "confluence_copy_page" {
+		if err := resolveArgument(call.Arguments, "src_workspace", prefs); err != nil {
+			return err
+		}
+		if dst, _ := call.Arguments["dst_workspace"].(string); strings.TrimSpace(dst) == "" {
+			call.Arguments["dst_workspace"] = call.Arguments["src_workspace"]
+		} else if err := resolveArgument(call.Arguments, "dst_workspace", prefs); err != nil {
+			return err
+		}
+		// The service looks up the source workspace first
+		call.Arguments["workspace_id"] = call.Arguments["src_workspace"]
+		return nil
+	}
+
+	return resolveArgument(call.Arguments, "workspace_id", prefs)
+}
+
+// RelaxSchemas returns the tools with workspace_id made optional when the user has a
+// default workspace. Tool definitions are copied, never modified.
+func (r *WorkspaceResolver) RelaxSchemas(tools []mcp.Tool, userID string) []mcp.Tool {
+	prefs, err := r.preferences(userID)
+	if err != nil || prefs == nil || prefs.DefaultWorkspace == "" {
+		return tools
+	}
+
+	relaxed := make([]mcp.Tool, len(tools))
+	for i, tool := range tools {
+		relaxed[i] = tool
+		if !usesWorkspace(tool.Name) {
+			continue
+		}
+
+		required, ok := tool.InputSchema["required"].([]string)
+		if !ok {
+			continue
+		}
+
+		schema := make(map[string]interface{}, len(tool.InputSchema))
+		for k, v := range tool.InputSchema {
+			schema[k] = v
+		}
+
+		var stillRequired []string
+		for _, name := range required {
+			if name != "workspace_id" && name != "src_workspace" && name != "dst_workspace" {
+				stillRequired = append(stillRequired, name)
+			}
+		}
+		schema["required"] = stillRequired
+
+		if properties, ok := tool.InputSchema["properties"].(map[string]interface{}); ok {
+			copied := make(map[string]interface{}, len(properties))
+			for name, property := range properties {
+				copied[name] = property
+				def, ok := property.(map[string]interface{})
+				// dst_workspace defaults to the source workspace, not the default workspace
+				if !ok || (name != "workspace_id" && name != "src_workspace") {
+					continue
+				}
+				withDefault := make(map[string]interface{}, len(def)+1)
+				for k, v := range def {
+					withDefault[k] = v
+				}
+				withDefault["description"] = fmt.Sprintf("%s (defaults to '%s')", def["description"], prefs.DefaultWorkspace)
+				copied[name] = withDefault
+			}
+			schema["properties"] = copied
+		}
+
+		relaxed[i].InputSchema = schema
+	}
+
+	return relaxed
+}
+
+// usesWorkspace reports whether a tool addresses a workspace that aliases and defaults apply to
+func usesWorkspace(toolName string) bool {
+	return strings.HasPrefix(toolName, "jira_") ||
+		strings.HasPrefix(toolName, "confluence_") ||
+		toolName == "workspace_status"
+}
+
+// resolveArgument resolves one workspace argument
+func resolveArgument(args map[string]interface{}, key string, prefs *models.WorkspacePreferences) error {
+	workspaceID, _ := args[key].(string)
+	workspaceID = strings.TrimSpace(workspaceID)
+
+	resolved := prefs.Resolve(workspaceID)
+	if resolved == "" {
+		return fmt.Errorf("%s is required (no default workspace is set; use set_default_workspace)", key)
+	}
+
+	args[key] = resolved
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	confluenceapi "github.com/providentiaww/trilix-atlassian-mcp/cmd/confluence-service/api"
+	confluencesvc "github.com/providentiaww/trilix-atlassian-mcp/cmd/confluence-service/handlers"
+	jiraapi "github.com/providentiaww/trilix-atlassian-mcp/cmd/jira-service/api"
+	jirasvc "github.com/providentiaww/trilix-atlassian-mcp/cmd/jira-service/handlers"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/oauth"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Service modes selected by SERVICE_MODE
+const (
+	ServiceModeRabbitMQ  = "rabbitmq"  // Default: Jira and Confluence services run as separate processes behind RabbitMQ
+	ServiceModeInProcess = "inprocess" // The services run inside the MCP server; no broker needed
+)
+
+// inProcessServices holds the Jira and Confluence services when they run inside the MCP server
+type inProcessServices struct {
+	jira              *jirasvc.Service
+	confluence        *confluencesvc.Service
+	jiraClients       *jiraapi.ClientPool
+	confluenceClients *confluenceapi.ClientPool
+}
+
+// newInProcessServices builds both services on the MCP server's credential store
+func newInProcessServices(credStore storage.CredentialStoreInterface) (*inProcessServices, error) {
+	clientOpts, err := atlassian.ClientOptionsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure API clients: %w", err)
+	}
+
+	oauthConfig, err := oauth.ConfigFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure OAuth: %w", err)
+	}
+
+	s := &inProcessServices{
+		jiraClients:       jiraapi.NewClientPool(clientOpts),
+		confluenceClients: confluenceapi.NewClientPool(clientOpts),
+	}
+	s.jira = jirasvc.NewService(credStore, s.jiraClients, oauthConfig)
+	s.confluence = confluencesvc.NewService(credStore, s.confluenceClients, oauthConfig)
+
+	return s, nil
+}
+
+// Close releases the pooled API clients
+func (s *inProcessServices) Close() {
+	s.jiraClients.Close()
+	s.confluenceClients.Close()
+}
+
+// jiraCaller calls the Jira service directly, using the same JSON messages as the RabbitMQ path
+func (s *inProcessServices) jiraCaller() func(models.JiraRequest) (*models.JiraResponse, error) {
+	return func(req models.JiraRequest) (*models.JiraResponse, error) {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var response models.JiraResponse
+		if err := json.Unmarshal(s.jira.HandleRequest(amqp.Delivery{Body: body}), &response); err != nil {
+			return nil, err
+		}
+
+		return &response, nil
+	}
+}
+
+// confluenceCaller calls the Confluence service directly, using the same JSON messages as the RabbitMQ path
+func (s *inProcessServices) confluenceCaller() func(models.ConfluenceRequest) (*models.ConfluenceResponse, error) {
+	return func(req models.ConfluenceRequest) (*models.ConfluenceResponse, error) {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var response models.ConfluenceResponse
+		if err := json.Unmarshal(s.confluence.HandleRequest(amqp.Delivery{Body: body}), &response); err != nil {
+			return nil, err
+		}
+
+		return &response, nil
+	}
+}
@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/providentiaww/twistygo"
 	"github.com/providentiaww/trilix-atlassian-mcp/cmd/mcp-server/auth"
 	"github.com/providentiaww/trilix-atlassian-mcp/cmd/mcp-server/handlers"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/oauth"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/rpc"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/storage"
 	"github.com/providentiaww/trilix-atlassian-mcp/pkg/mcp"
-	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 const ServiceVersion = "v1.0.0"
@@ -25,6 +32,10 @@ func init() {
 
 	// Initialize TwistyGo
 	twistygo.LogStartService("MCPServer", ServiceVersion)
+}
+
+// connectRabbitMQ connects to the broker used to reach the Jira and Confluence services
+func connectRabbitMQ() {
 	rconn = twistygo.AmqpConnect()
 	rconn.AmqpLoadQueues("ConfluenceRequests", "JiraRequests")
 }
@@ -37,14 +48,47 @@ func main() {
 	}
 	defer credStore.Close()
 
-	// Create service callers
-	confluenceCaller := createConfluenceCaller()
-	jiraCaller := createJiraCaller()
+	// Create service callers: over RabbitMQ, or in-process for single-binary installs
+	var confluenceCaller func(models.ConfluenceRequest) (*models.ConfluenceResponse, error)
+	var jiraCaller func(models.JiraRequest) (*models.JiraResponse, error)
+	switch mode := os.Getenv("SERVICE_MODE"); mode {
+	case "", ServiceModeRabbitMQ:
+		rpcConfig, err := rpc.ConfigFromEnv()
+		if err != nil {
+			panic(fmt.Sprintf("Invalid RPC configuration: %v", err))
+		}
+		connectRabbitMQ()
+		confluenceCaller = createConfluenceCaller(rpc.NewClient("confluence", rpcConfig))
+		jiraCaller = createJiraCaller(rpc.NewClient("jira", rpcConfig))
+	case ServiceModeInProcess:
+		services, err := newInProcessServices(credStore)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to start in-process services: %v", err))
+		}
+		defer services.Close()
+		confluenceCaller = services.confluenceCaller()
+		jiraCaller = services.jiraCaller()
+	default:
+		panic(fmt.Sprintf("Invalid SERVICE_MODE %q (expected %q or %q)", mode, ServiceModeRabbitMQ, ServiceModeInProcess))
+	}
 
 	// Create handlers
 	confluenceHandler := handlers.NewConfluenceHandler(confluenceCaller)
 	jiraHandler := handlers.NewJiraHandler(jiraCaller)
-	managementHandler := handlers.NewManagementHandler(credStore)
+	validator, err := newTokenValidator()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create credential validator: %v", err))
+	}
+	defer validator.Close()
+	managementHandler := handlers.NewManagementHandler(credStore, jiraCaller, confluenceCaller, validator.Validate)
+
+	// Start the HTTP listener for browser-based flows (Atlassian OAuth consent, workspace management)
+	if err := startHTTPServer(credStore, managementHandler); err != nil {
+		panic(fmt.Sprintf("Failed to start HTTP server: %v", err))
+	}
+	if err := startAdminServer(); err != nil {
+		panic(fmt.Sprintf("Failed to start admin server: %v", err))
+	}
 
 	// Create MCP server
 	server := mcp.NewServer()
@@ -60,18 +104,39 @@ func main() {
 		server.RegisterTool(tool)
 	}
 
+	// Make workspace_id optional in tool schemas once a default workspace is set
+	resolver := handlers.NewWorkspaceResolver(credStore)
+	server.SetToolFilter(func(tools []mcp.Tool) []mcp.Tool {
+		return resolver.RelaxSchemas(tools, "")
+	})
+
 	// Start server with handler
 	server.Start(func(call mcp.ToolCall) (mcp.ToolResult, error) {
 		// Extract user ID from metadata (if available)
 		// For now, use empty string - in production, extract from MCP request
 		userID := ""
 
+		// Apply workspace aliases and the default workspace
+		if err := resolver.Resolve(&call, userID); err != nil {
+			return mcp.ToolResult{
+				Content: []mcp.ContentBlock{
+					{Type: "text", Text: fmt.Sprintf("Error: %v", err)},
+				},
+				IsError: true,
+			}, err
+		}
+
+		// Setting a default changes which tool arguments are required
+		if call.Name == "set_default_workspace" {
+			defer server.NotifyToolsChanged()
+		}
+
 		// Route to appropriate handler
-		if call.Name == "list_workspaces" || call.Name == "workspace_status" {
+		if managementHandler.HasTool(call.Name) {
 			return managementHandler.HandleTool(call, userID)
-		} else if call.Name[:10] == "confluence_" {
+		} else if strings.HasPrefix(call.Name, "confluence_") {
 			return confluenceHandler.HandleTool(call, userID)
-		} else if call.Name[:5] == "jira_" {
+		} else if strings.HasPrefix(call.Name, "jira_") {
 			return jiraHandler.HandleTool(call, userID)
 		}
 
@@ -84,19 +149,106 @@ func main() {
 	})
 }
 
-func createConfluenceCaller() func(models.ConfluenceRequest) (*models.ConfluenceResponse, error) {
-	return func(req models.ConfluenceRequest) (*models.ConfluenceResponse, error) {
-		// Connect to ConfluenceRequests queue
-		sq := rconn.AmqpConnectQueue("ConfluenceRequests")
-		sq.SetEncoding(twistygo.EncodingJson)
+// startHTTPServer serves the OAuth and workspace management endpoints on HTTP_LISTEN_ADDR
+// (e.g., "127.0.0.1:8085"). Nothing is started when HTTP_LISTEN_ADDR is unset, since MCP
+// itself runs over stdio. Workspace management needs Clerk (CLERK_SECRET_KEY) to
+// identify callers and is not served without it.
+func startHTTPServer(credStore storage.CredentialStoreInterface, managementHandler *handlers.ManagementHandler) error {
+	addr := os.Getenv("HTTP_LISTEN_ADDR")
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	var userID func(*http.Request) (string, error)
+	if clerk := auth.NewClerkAuth(); clerk != nil {
+		userID = clerk.UserIDFromRequest
+	}
+	if err := managementHandler.RegisterRoutes(mux, userID); err != nil {
+		// stdout carries MCP messages, so warnings go to stderr
+		fmt.Fprintf(os.Stderr, "Workspace management endpoint disabled: %v (set CLERK_SECRET_KEY)\n", err)
+	}
+
+	oauthConfig, err := oauth.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if oauthConfig != nil {
+		auth.NewAtlassianOAuthHandler(oauthConfig, credStore).RegisterRoutes(mux)
+	}
+
+	go func() {
+		// stdout carries MCP messages, so errors go to stderr
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "HTTP server stopped: %v\n", err)
+		}
+	}()
+
+	return nil
+}
+
+// startAdminServer serves the /debug/vars counters on ADMIN_LISTEN_ADDR, apart from the
+// public listener. The counters are unauthenticated, so the address must be a loopback
+// one; nothing is started when ADMIN_LISTEN_ADDR is unset.
+func startAdminServer() error {
+	addr := os.Getenv("ADMIN_LISTEN_ADDR")
+	if addr == "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid ADMIN_LISTEN_ADDR %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("ADMIN_LISTEN_ADDR %q must be a loopback address", addr)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	go func() {
+		// stdout carries MCP messages, so errors go to stderr
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "Admin server stopped: %v\n", err)
+		}
+	}()
+
+	return nil
+}
 
-		// Append request data
-		sq.Message.AppendData(req)
+// publishRequest publishes a request to a service queue and waits for the reply.
+// twistygo cannot interrupt a publish that is waiting, so a request whose deadline has
+// already passed is not sent, a reply arriving after it is dropped, and the rpc client
+// limits how many publishes may still be waiting (RPC_MAX_PENDING).
+func publishRequest(ctx context.Context, queue string, req any) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sq := rconn.AmqpConnectQueue(queue)
+	sq.SetEncoding(twistygo.EncodingJson)
+	sq.Message.AppendData(req)
 
-		// Publish and wait for response (RPC)
-		responseBytes, err := sq.Publish()
+	response, err := sq.Publish()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return response, err
+}
+
+func createConfluenceCaller(client *rpc.Client) func(models.ConfluenceRequest) (*models.ConfluenceResponse, error) {
+	return func(req models.ConfluenceRequest) (*models.ConfluenceResponse, error) {
+		// Publish and wait for response (RPC), bounded by the action's deadline
+		responseBytes, err := client.Call(req.Action, func(ctx context.Context) ([]byte, error) {
+			return publishRequest(ctx, "ConfluenceRequests", req)
+		})
 		if err != nil {
-			return nil, err
+			return &models.ConfluenceResponse{
+				RequestID: req.RequestID,
+				Success:   false,
+				Error:     rpcErrorInfo(err),
+			}, nil
 		}
 
 		// Unmarshal response
@@ -109,19 +261,18 @@ func createConfluenceCaller() func(models.ConfluenceRequest) (*models.Confluence
 	}
 }
 
-func createJiraCaller() func(models.JiraRequest) (*models.JiraResponse, error) {
+func createJiraCaller(client *rpc.Client) func(models.JiraRequest) (*models.JiraResponse, error) {
 	return func(req models.JiraRequest) (*models.JiraResponse, error) {
-		// Connect to JiraRequests queue
-		sq := rconn.AmqpConnectQueue("JiraRequests")
-		sq.SetEncoding(twistygo.EncodingJson)
-
-		// Append request data
-		sq.Message.AppendData(req)
-
-		// Publish and wait for response (RPC)
-		responseBytes, err := sq.Publish()
+		// Publish and wait for response (RPC), bounded by the action's deadline
+		responseBytes, err := client.Call(req.Action, func(ctx context.Context) ([]byte, error) {
+			return publishRequest(ctx, "JiraRequests", req)
+		})
 		if err != nil {
-			return nil, err
+			return &models.JiraResponse{
+				RequestID: req.RequestID,
+				Success:   false,
+				Error:     rpcErrorInfo(err),
+			}, nil
 		}
 
 		// Unmarshal response
@@ -134,3 +285,15 @@ func createJiraCaller() func(models.JiraRequest) (*models.JiraResponse, error) {
 	}
 }
 
+
+// rpcErrorInfo describes a service call that failed before the service answered
+func rpcErrorInfo(err error) *models.ErrorInfo {
+	code := models.ErrCodeUnavailable
+	if errors.Is(err, rpc.ErrTimeout) {
+		code = models.ErrCodeTimeout
+	}
+	return &models.ErrorInfo{
+		Code:    code,
+		Message: err.Error(),
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	confluenceapi "github.com/providentiaww/trilix-atlassian-mcp/cmd/confluence-service/api"
+	jiraapi "github.com/providentiaww/trilix-atlassian-mcp/cmd/jira-service/api"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/atlassian"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// tokenValidator checks API token credentials against the product APIs from inside the
+// MCP server, so tokens being onboarded never travel over RabbitMQ
+type tokenValidator struct {
+	jiraClients       *jiraapi.ClientPool
+	confluenceClients *confluenceapi.ClientPool
+}
+
+// newTokenValidator creates a validator with its own API clients
+func newTokenValidator() (*tokenValidator, error) {
+	clientOpts, err := atlassian.ClientOptionsFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure API clients: %w", err)
+	}
+
+	return &tokenValidator{
+		jiraClients:       jiraapi.NewClientPool(clientOpts),
+		confluenceClients: confluenceapi.NewClientPool(clientOpts),
+	}, nil
+}
+
+// Close releases the API clients
+func (v *tokenValidator) Close() {
+	v.jiraClients.Close()
+	v.confluenceClients.Close()
+}
+
+// Validate authenticates the credentials with a product: Jira's /myself or
+// Confluence's /user/current
+func (v *tokenValidator) Validate(creds *models.WorkspaceCredentials, product string) (*models.CredentialCheck, error) {
+	apiCreds := atlassian.CredentialsFor(creds, product, nil)
+	check := &models.CredentialCheck{Product: product, BaseURL: apiCreds.Site}
+
+	switch product {
+	case models.ProductJira:
+		user, err := v.jiraClients.NewClient(apiCreds).GetMyself()
+		if err != nil {
+			return nil, err
+		}
+		check.AccountID = user.AccountID
+		if check.AccountID == "" {
+			check.AccountID = user.Name
+		}
+		check.DisplayName, check.Email = user.DisplayName, user.Email
+	case models.ProductConfluence:
+		user, err := v.confluenceClients.NewClient(apiCreds).GetCurrentUser()
+		if err != nil {
+			return nil, err
+		}
+		check.AccountID = user.AccountID
+		if check.AccountID == "" {
+			check.AccountID = user.Username
+		}
+		check.DisplayName, check.Email = user.DisplayName, user.Email
+	default:
+		return nil, fmt.Errorf("unknown product %q", product)
+	}
+
+	return check, nil
+}
@@ -0,0 +1,96 @@
+// Package adf builds and reads Atlassian Document Format (ADF), the rich text format
+// of Jira Cloud descriptions and comments in REST API v3
+package adf
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Node is an ADF node. A document is a node of type "doc" with Version 1.
+type Node struct {
+	Type    string         `json:"type"`
+	Version int            `json:"version,omitempty"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+	Content []*Node        `json:"content,omitempty"`
+	Text    string         `json:"text,omitempty"`
+	Marks   []Mark         `json:"marks,omitempty"`
+}
+
+// Mark is formatting applied to a text node, such as strong or link
+type Mark struct {
+	Type  string         `json:"type"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// Doc returns a document with the given blocks. An empty document gets an empty
+// paragraph, since ADF requires the document's content.
+func Doc(blocks ...*Node) *Node {
+	if len(blocks) == 0 {
+		blocks = []*Node{{Type: "paragraph"}}
+	}
+	return &Node{Type: "doc", Version: 1, Content: blocks}
+}
+
+// FromText builds a document from plain text. Blank lines separate paragraphs; other
+// line breaks are kept as hard breaks.
+func FromText(text string) *Node {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var paragraphs []*Node
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.Trim(para, "\n")
+		if strings.TrimSpace(para) == "" {
+			continue
+		}
+
+		p := &Node{Type: "paragraph"}
+		for i, line := range strings.Split(para, "\n") {
+			if i > 0 {
+				p.Content = append(p.Content, &Node{Type: "hardBreak"})
+			}
+			if line != "" {
+				p.Content = append(p.Content, &Node{Type: "text", Text: line})
+			}
+		}
+		paragraphs = append(paragraphs, p)
+	}
+
+	return Doc(paragraphs...)
+}
+
+// Parse reads a document from a decoded JSON value, such as an issue's description
+// field. It reports false when the value is not a document.
+func Parse(v any) (*Node, bool) {
+	m, ok := v.(map[string]any)
+	if !ok || m["type"] != "doc" {
+		return nil, false
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, false
+	}
+	var doc Node
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false
+	}
+	return &doc, true
+}
+
+// attr returns a string attribute of a node, or ""
+func (n *Node) attr(name string) string {
+	s, _ := n.Attrs[name].(string)
+	return s
+}
+
+// intAttr returns a numeric attribute of a node, or def
+func (n *Node) intAttr(name string, def int) int {
+	if f, ok := n.Attrs[name].(float64); ok {
+		return int(f)
+	}
+	if i, ok := n.Attrs[name].(int); ok {
+		return i
+	}
+	return def
+}
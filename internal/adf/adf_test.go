@@ -0,0 +1,127 @@
+package adf
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update rewrites the golden files from the current output: go test ./internal/adf -update
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// goldenCases runs fn on every input file in dir with the given extension and compares
+// its output to the input's .golden file
+func goldenCases(t *testing.T, dir, ext string, fn func(t *testing.T, input string) string) {
+	t.Helper()
+	inputs, err := filepath.Glob(filepath.Join("testdata", dir, "*"+ext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatalf("no test cases in testdata/%s", dir)
+	}
+
+	for _, path := range inputs {
+		name := strings.TrimSuffix(filepath.Base(path), ext)
+		t.Run(name, func(t *testing.T) {
+			input, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := fn(t, string(input))
+
+			golden := strings.TrimSuffix(path, ext) + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("output differs from %s\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+// marshal renders a document as indented JSON
+func marshal(t *testing.T, doc *Node) string {
+	t.Helper()
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(data) + "\n"
+}
+
+// parseDoc reads a document from JSON the way issue fields are decoded
+func parseDoc(t *testing.T, data string) *Node {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	doc, ok := Parse(v)
+	if !ok {
+		t.Fatal("Parse: not a document")
+	}
+	return doc
+}
+
+func TestFromMarkdownGolden(t *testing.T) {
+	goldenCases(t, "from_markdown", ".md", func(t *testing.T, md string) string {
+		return marshal(t, FromMarkdown(md))
+	})
+}
+
+func TestFromTextGolden(t *testing.T) {
+	goldenCases(t, "from_text", ".txt", func(t *testing.T, text string) string {
+		return marshal(t, FromText(text))
+	})
+}
+
+func TestToMarkdownGolden(t *testing.T) {
+	goldenCases(t, "to_markdown", ".json", func(t *testing.T, data string) string {
+		return ToMarkdown(parseDoc(t, data)) + "\n"
+	})
+}
+
+// TestRoundTrip checks that Markdown read back from an issue reads the same after it
+// is written again, so a read-edit-write cycle only changes what the agent edited. The
+// ToMarkdown cases are not round-tripped: ADF such as mentions, nested task lists and
+// error panels has no exact Markdown equivalent by design.
+func TestRoundTrip(t *testing.T) {
+	goldens, err := filepath.Glob(filepath.Join("testdata", "from_markdown", "*.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range goldens {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".golden"), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			md := ToMarkdown(parseDoc(t, string(data)))
+			if again := ToMarkdown(FromMarkdown(md)); again != md {
+				t.Errorf("Markdown changed in a round trip\n--- before ---\n%s\n--- after ---\n%s", md, again)
+			}
+		})
+	}
+}
+
+func TestParseRejectsNonDocuments(t *testing.T) {
+	for _, v := range []any{nil, "plain text", map[string]any{"type": "paragraph"}} {
+		if _, ok := Parse(v); ok {
+			t.Errorf("Parse(%#v) = ok, want not a document", v)
+		}
+	}
+}
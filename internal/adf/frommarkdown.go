@@ -0,0 +1,339 @@
+package adf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/markdown"
+)
+
+// panelTypes maps the Confluence message macros that markdown.ToStorage emits for
+// GitHub alerts to ADF panel types
+var panelTypes = map[string]string{
+	"info":    "info",
+	"tip":     "success",
+	"note":    "note",
+	"warning": "warning",
+}
+
+// element is a storage format element or, when name is empty, a run of text
+type element struct {
+	name     string
+	attrs    map[string]string
+	children []*element
+	text     string
+}
+
+// builder converts storage format to ADF, numbering task lists and items as it goes
+type builder struct {
+	localIDs int
+}
+
+// FromMarkdown builds a document from Markdown. The Markdown is read by
+// markdown.ToStorage, so both formats support the same syntax.
+func FromMarkdown(md string) *Node {
+	root, err := parseStorage(markdown.ToStorage(md))
+	if err != nil {
+		// ToStorage escapes all input, so its output always parses
+		return FromText(md)
+	}
+
+	b := &builder{}
+	return Doc(b.blocks(root.children)...)
+}
+
+// parseStorage reads the storage format produced by markdown.ToStorage into a tree
+func parseStorage(storage string) (*element, error) {
+	d := xml.NewDecoder(strings.NewReader("<root>" + storage + "</root>"))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	var root *element
+	var stack []*element
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &element{name: t.Name.Local, attrs: make(map[string]string)}
+			if t.Name.Space != "" {
+				e.name = t.Name.Space + ":" + t.Name.Local
+			}
+			for _, a := range t.Attr {
+				name := a.Name.Local
+				if a.Name.Space != "" {
+					name = a.Name.Space + ":" + name
+				}
+				e.attrs[name] = a.Value
+			}
+			if len(stack) == 0 {
+				root = e
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &element{text: string(t)})
+			}
+		}
+	}
+	return root, nil
+}
+
+// child returns the first child element with a name, or nil
+func (e *element) child(name string) *element {
+	for _, c := range e.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// textContent returns the text of an element and its descendants
+func (e *element) textContent() string {
+	if e.name == "" {
+		return e.text
+	}
+	var b strings.Builder
+	for _, c := range e.children {
+		b.WriteString(c.textContent())
+	}
+	return b.String()
+}
+
+// isBlock reports whether an element becomes a block node
+func (e *element) isBlock() bool {
+	switch e.name {
+	case "p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "ac:task-list", "table",
+		"blockquote", "hr", "ac:structured-macro":
+		return true
+	}
+	return false
+}
+
+// localID returns a new ID for a task list or item, unique within the document
+func (b *builder) localID() string {
+	b.localIDs++
+	return fmt.Sprintf("task-%d", b.localIDs)
+}
+
+// blocks converts elements to block nodes; runs of inline elements become paragraphs
+func (b *builder) blocks(elements []*element) []*Node {
+	var nodes []*Node
+	var run []*element
+	flush := func() {
+		if content := b.inlines(run, nil); len(content) > 0 {
+			nodes = append(nodes, &Node{Type: "paragraph", Content: content})
+		}
+		run = nil
+	}
+
+	for _, e := range elements {
+		if !e.isBlock() {
+			run = append(run, e)
+			continue
+		}
+		flush()
+		if n := b.block(e); n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	flush()
+
+	return nodes
+}
+
+// block converts one block element
+func (b *builder) block(e *element) *Node {
+	switch e.name {
+	case "p":
+		return &Node{Type: "paragraph", Content: b.inlines(e.children, nil)}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		return &Node{Type: "heading", Attrs: map[string]any{"level": int(e.name[1] - '0')}, Content: b.inlines(e.children, nil)}
+	case "hr":
+		return &Node{Type: "rule"}
+	case "blockquote":
+		return &Node{Type: "blockquote", Content: b.blocks(e.children)}
+	case "ul", "ol":
+		list := &Node{Type: "bulletList"}
+		if e.name == "ol" {
+			list.Type = "orderedList"
+		}
+		for _, li := range e.children {
+			if li.name == "li" {
+				list.Content = append(list.Content, &Node{Type: "listItem", Content: nonEmpty(b.blocks(li.children))})
+			}
+		}
+		return list
+	case "ac:task-list":
+		list := &Node{Type: "taskList", Attrs: map[string]any{"localId": b.localID()}}
+		for _, task := range e.children {
+			if task.name != "ac:task" {
+				continue
+			}
+			state := "TODO"
+			if status := task.child("ac:task-status"); status != nil && status.textContent() == "complete" {
+				state = "DONE"
+			}
+			item := &Node{Type: "taskItem", Attrs: map[string]any{"localId": b.localID(), "state": state}}
+			if body := task.child("ac:task-body"); body != nil {
+				item.Content = b.inlines(body.children, nil)
+			}
+			list.Content = append(list.Content, item)
+		}
+		return list
+	case "table":
+		return b.table(e)
+	case "ac:structured-macro":
+		return b.macro(e)
+	}
+	return nil
+}
+
+// table converts a table; header cells stay header cells
+func (b *builder) table(e *element) *Node {
+	table := &Node{Type: "table"}
+	var rows func(*element)
+	rows = func(e *element) {
+		for _, c := range e.children {
+			switch c.name {
+			case "tbody", "thead", "tfoot":
+				rows(c)
+			case "tr":
+				row := &Node{Type: "tableRow"}
+				for _, cell := range c.children {
+					switch cell.name {
+					case "th":
+						row.Content = append(row.Content, &Node{Type: "tableHeader", Content: nonEmpty(b.blocks(cell.children))})
+					case "td":
+						row.Content = append(row.Content, &Node{Type: "tableCell", Content: nonEmpty(b.blocks(cell.children))})
+					}
+				}
+				table.Content = append(table.Content, row)
+			}
+		}
+	}
+	rows(e)
+	return table
+}
+
+// macro converts the code and message macros markdown.ToStorage emits
+func (b *builder) macro(e *element) *Node {
+	name := e.attrs["ac:name"]
+	if name == "code" {
+		code := &Node{Type: "codeBlock"}
+		for _, c := range e.children {
+			if c.name == "ac:parameter" && c.attrs["ac:name"] == "language" {
+				code.Attrs = map[string]any{"language": c.textContent()}
+			}
+		}
+		if body := e.child("ac:plain-text-body"); body != nil && body.textContent() != "" {
+			code.Content = []*Node{{Type: "text", Text: body.textContent()}}
+		}
+		return code
+	}
+
+	panelType, ok := panelTypes[name]
+	if !ok {
+		return nil
+	}
+	panel := &Node{Type: "panel", Attrs: map[string]any{"panelType": panelType}}
+	if body := e.child("ac:rich-text-body"); body != nil {
+		panel.Content = b.blocks(body.children)
+	}
+	panel.Content = nonEmpty(panel.Content)
+	return panel
+}
+
+// inlines converts inline elements to text and hard break nodes, applying marks from
+// enclosing elements
+func (b *builder) inlines(elements []*element, marks []Mark) []*Node {
+	var nodes []*Node
+	for _, e := range elements {
+		switch e.name {
+		case "":
+			text := strings.ReplaceAll(e.text, "\n", " ")
+			if len(nodes) > 0 && nodes[len(nodes)-1].Type == "hardBreak" {
+				text = strings.TrimLeft(text, " ")
+			}
+			if text != "" {
+				nodes = append(nodes, &Node{Type: "text", Text: text, Marks: marks})
+			}
+		case "br":
+			nodes = append(nodes, &Node{Type: "hardBreak"})
+		case "strong":
+			nodes = append(nodes, b.inlines(e.children, withMark(marks, Mark{Type: "strong"}))...)
+		case "em":
+			nodes = append(nodes, b.inlines(e.children, withMark(marks, Mark{Type: "em"}))...)
+		case "del":
+			nodes = append(nodes, b.inlines(e.children, withMark(marks, Mark{Type: "strike"}))...)
+		case "code":
+			// Code combines only with links
+			codeMarks := []Mark{{Type: "code"}}
+			for _, m := range marks {
+				if m.Type == "link" {
+					codeMarks = append(codeMarks, m)
+				}
+			}
+			if text := e.textContent(); text != "" {
+				nodes = append(nodes, &Node{Type: "text", Text: text, Marks: codeMarks})
+			}
+		case "a":
+			link := Mark{Type: "link", Attrs: map[string]any{"href": e.attrs["href"]}}
+			nodes = append(nodes, b.inlines(e.children, withMark(marks, link))...)
+		case "ac:image":
+			// Issue fields cannot embed images by URL, so images become links
+			alt := e.attrs["ac:alt"]
+			if ri := e.child("ri:url"); ri != nil {
+				if alt == "" {
+					alt = ri.attrs["ri:value"]
+				}
+				link := Mark{Type: "link", Attrs: map[string]any{"href": ri.attrs["ri:value"]}}
+				nodes = append(nodes, &Node{Type: "text", Text: alt, Marks: withMark(marks, link)})
+			} else if ri := e.child("ri:attachment"); ri != nil {
+				if alt == "" {
+					alt = ri.attrs["ri:filename"]
+				}
+				nodes = append(nodes, &Node{Type: "text", Text: alt, Marks: marks})
+			}
+		case "ac:link":
+			// Page links have no meaning in Jira; keep their text
+			if body := e.child("ac:link-body"); body != nil {
+				nodes = append(nodes, b.inlines(body.children, marks)...)
+			}
+		default:
+			nodes = append(nodes, b.inlines(e.children, marks)...)
+		}
+	}
+	return nodes
+}
+
+// withMark returns marks with one more mark, leaving marks itself unchanged
+func withMark(marks []Mark, mark Mark) []Mark {
+	return append(append([]Mark(nil), marks...), mark)
+}
+
+// nonEmpty returns blocks, or one empty paragraph when there are none, for nodes
+// that must have content
+func nonEmpty(blocks []*Node) []*Node {
+	if len(blocks) == 0 {
+		return []*Node{{Type: "paragraph"}}
+	}
+	return blocks
+}
@@ -0,0 +1,329 @@
+package adf
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	lineStartPattern = regexp.MustCompile(`^(#{1,6}[ \t]|>|[-+*][ \t]|\d{1,9}[.)][ \t])`)
+	textEscaper      = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "[", `\[`, "]", `\]`)
+)
+
+// alertTypes maps ADF panel types to the GitHub alerts that render alike
+var alertTypes = map[string]string{
+	"info":    "NOTE",
+	"success": "TIP",
+	"note":    "IMPORTANT",
+	"warning": "WARNING",
+	"error":   "CAUTION",
+}
+
+// ToMarkdown renders a document as Markdown. Nodes with no Markdown equivalent, such
+// as mentions and status lozenges, are rendered as their text.
+func ToMarkdown(doc *Node) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(renderBlocks(doc.Content, "\n\n"))
+}
+
+// renderBlocks renders block nodes joined by sep
+func renderBlocks(nodes []*Node, sep string) string {
+	var parts []string
+	for _, n := range nodes {
+		if text := renderBlock(n); strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// renderBlock renders one block node
+func renderBlock(n *Node) string {
+	switch n.Type {
+	case "paragraph":
+		text := strings.TrimRight(renderInlines(n.Content), " ")
+		for strings.HasSuffix(text, "\\\n") {
+			text = strings.TrimRight(strings.TrimSuffix(text, "\\\n"), " ")
+		}
+		if lineStartPattern.MatchString(text) {
+			text = `\` + text
+		}
+		return text
+	case "heading":
+		text := strings.ReplaceAll(strings.TrimSpace(renderInlines(n.Content)), "\\\n", " ")
+		return strings.Repeat("#", n.intAttr("level", 1)) + " " + text
+	case "bulletList", "orderedList", "taskList", "decisionList":
+		return renderList(n)
+	case "codeBlock":
+		var code strings.Builder
+		for _, c := range n.Content {
+			code.WriteString(c.Text)
+		}
+		return fence(code.String(), n.attr("language"))
+	case "blockquote":
+		return quoteLines(renderBlocks(n.Content, "\n\n"))
+	case "panel":
+		alert, ok := alertTypes[n.attr("panelType")]
+		if !ok {
+			alert = "NOTE"
+		}
+		return "> [!" + alert + "]\n" + quoteLines(renderBlocks(n.Content, "\n\n"))
+	case "rule":
+		return "---"
+	case "table":
+		return renderTable(n)
+	case "mediaSingle", "mediaGroup":
+		var media []string
+		for _, m := range n.Content {
+			media = append(media, renderInline(m))
+		}
+		return strings.Join(media, " ")
+	case "expand", "nestedExpand":
+		text := renderBlocks(n.Content, "\n\n")
+		if title := n.attr("title"); title != "" {
+			text = "**" + textEscaper.Replace(title) + "**\n\n" + text
+		}
+		return text
+	case "blockCard", "embedCard":
+		return "<" + n.attr("url") + ">"
+	}
+
+	if n.Text != "" || isInline(n.Content) {
+		return renderInlines(n.Content)
+	}
+	return renderBlocks(n.Content, "\n\n")
+}
+
+// renderList renders a bullet, ordered, task or decision list; nested content is
+// indented under its item
+func renderList(n *Node) string {
+	number := n.intAttr("order", 1)
+
+	var items []string
+	for _, item := range n.Content {
+		if item.Type == "taskList" {
+			// Nested task lists are siblings of the items they belong to
+			items = append(items, indentLines("  "+renderList(item), 2))
+			continue
+		}
+
+		marker := "- "
+		switch {
+		case n.Type == "orderedList":
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		case item.Type == "taskItem" && item.attr("state") == "DONE":
+			marker = "- [x] "
+		case item.Type == "taskItem":
+			marker = "- [ ] "
+		}
+
+		var content string
+		if isInline(item.Content) {
+			content = strings.TrimSpace(renderInlines(item.Content))
+		} else {
+			content = renderBlocks(item.Content, "\n")
+		}
+		items = append(items, marker+indentLines(content, len(marker)))
+	}
+	return strings.Join(items, "\n")
+}
+
+// renderTable renders a table as a GitHub table whose header is the first row. Cell
+// content is flattened to one line.
+func renderTable(n *Node) string {
+	var rows [][]string
+	columns := 0
+	for _, row := range n.Content {
+		var cells []string
+		for _, cell := range row.Content {
+			text := strings.TrimSpace(renderBlocks(cell.Content, "\n"))
+			text = strings.ReplaceAll(text, "\\\n", "<br>")
+			text = strings.ReplaceAll(text, "\n", "<br>")
+			cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+		}
+		rows = append(rows, cells)
+		columns = max(columns, len(cells))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for r, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if r == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// renderInlines renders inline nodes. Lines after a hard break are escaped where they
+// would start a heading, quote or list.
+func renderInlines(nodes []*Node) string {
+	var b strings.Builder
+	for i, n := range nodes {
+		text := renderInline(n)
+		if i > 0 && nodes[i-1].Type == "hardBreak" && lineStartPattern.MatchString(text) {
+			text = `\` + text
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// renderInline renders one inline node
+func renderInline(n *Node) string {
+	switch n.Type {
+	case "text":
+		return renderText(n)
+	case "hardBreak":
+		return "\\\n"
+	case "mention":
+		text := n.attr("text")
+		if text == "" {
+			text = n.attr("id")
+		}
+		if !strings.HasPrefix(text, "@") {
+			text = "@" + text
+		}
+		return textEscaper.Replace(text)
+	case "emoji":
+		if text := n.attr("text"); text != "" {
+			return text
+		}
+		return n.attr("shortName")
+	case "inlineCard":
+		return "<" + n.attr("url") + ">"
+	case "status":
+		return "[" + textEscaper.Replace(n.attr("text")) + "]"
+	case "date":
+		if ms, err := strconv.ParseInt(n.attr("timestamp"), 10, 64); err == nil {
+			return time.UnixMilli(ms).UTC().Format("2006-01-02")
+		}
+		return n.attr("timestamp")
+	case "media":
+		alt := n.attr("alt")
+		if n.attr("type") == "external" {
+			return "![" + textEscaper.Replace(alt) + "](" + n.attr("url") + ")"
+		}
+		if alt == "" {
+			alt = "attachment"
+		}
+		return "[" + textEscaper.Replace(alt) + "]"
+	}
+	return renderInlines(n.Content)
+}
+
+// renderText renders a text node with its marks; code is innermost and links outermost
+func renderText(n *Node) string {
+	text := textEscaper.Replace(n.Text)
+	var href string
+	for _, m := range n.Marks {
+		if m.Type == "code" {
+			text = codeSpan(n.Text)
+		}
+	}
+	for _, m := range n.Marks {
+		switch m.Type {
+		case "strong":
+			text = wrap(text, "**")
+		case "em":
+			text = wrap(text, "*")
+		case "strike":
+			text = wrap(text, "~~")
+		case "link":
+			href, _ = m.Attrs["href"].(string)
+		}
+	}
+	if href != "" {
+		text = "[" + text + "](" + href + ")"
+	}
+	return text
+}
+
+// isInline reports whether nodes are inline content
+func isInline(nodes []*Node) bool {
+	for _, n := range nodes {
+		switch n.Type {
+		case "text", "hardBreak", "mention", "emoji", "inlineCard", "status", "date":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// wrap surrounds text with an emphasis delimiter, keeping surrounding spaces outside it
+func wrap(text, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + delimiter + trimmed + delimiter + text[start+len(trimmed):]
+}
+
+// codeSpan returns a code span showing code, using more backticks than code contains
+func codeSpan(code string) string {
+	ticks := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		return ticks + " " + code + " " + ticks
+	}
+	return ticks + code + ticks
+}
+
+// fence returns a fenced code block showing code
+func fence(code, language string) string {
+	code = strings.Trim(code, "\n")
+	ticks := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	return ticks + language + "\n" + code + "\n" + ticks
+}
+
+// quoteLines prefixes each line of text with "> "
+func quoteLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentLines indents every line of text but the first by n spaces
+func indentLines(text string, n int) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = pad + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// longestRun returns the length of the longest run of c in s
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
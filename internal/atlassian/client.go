@@ -0,0 +1,209 @@
+package atlassian
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MinTransferRate is the slowest file transfer, in bytes per second, that a transfer's
+// deadline allows for
+const MinTransferRate = 64 << 10
+
+// ErrAuthorize marks failures to obtain credentials for a request, e.g., an OAuth 2.0
+// refresh that was rejected
+var ErrAuthorize = errors.New("could not authorize request")
+
+// Client sends authenticated JSON requests to one Atlassian product site.
+// The Jira and Confluence clients build their endpoints on it.
+type Client struct {
+	creds      Credentials
+	auth       Authenticator
+	httpClient *http.Client
+	transfer   *http.Client // httpClient without its overall timeout, for file transfers
+}
+
+// NewClient creates a client; a nil httpClient gets one built from DefaultClientOptions
+func NewClient(creds Credentials, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = NewHTTPClient(DefaultClientOptions())
+	}
+	transfer := *httpClient
+	transfer.Timeout = 0
+	return &Client{
+		creds:      creds,
+		auth:       creds.Authenticator(),
+		httpClient: httpClient,
+		transfer:   &transfer,
+	}
+}
+
+// Credentials returns the credentials the client was built with
+func (c *Client) Credentials() Credentials {
+	return c.creds
+}
+
+// Site returns the product base URL
+func (c *Client) Site() string {
+	return c.creds.Site
+}
+
+// IsDataCenter reports whether the client talks to a self-hosted Data Center instance
+func (c *Client) IsDataCenter() bool {
+	return c.creds.IsDataCenter()
+}
+
+// NewRequest builds an authenticated request that accepts JSON. A non-nil body is
+// sent as JSON, or as is when it is already an io.Reader.
+func (c *Client) NewRequest(method, url string, body any) (*http.Request, error) {
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	default:
+		payload, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.auth.Authenticate(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthorize, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return req, nil
+}
+
+// Do sends a request and returns the raw response, whatever its status
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.httpClient.Do(req)
+}
+
+// DoJSON sends a request, turns a non-2xx response into an *HTTPError describing
+// op, and decodes a JSON response body into out when out is non-nil
+func (c *Client) DoJSON(req *http.Request, op string, out any) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, op, out)
+}
+
+// Transfer sends a request that moves size bytes of file content, in its body or its
+// response. The client's overall timeout would cut off large files, so the deadline
+// is TransferTimeout instead; it ends when the response body is closed.
+func (c *Client) Transfer(req *http.Request, size int64) (*http.Response, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout := TransferTimeout(c.httpClient.Timeout, size); timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+
+	resp, err := c.transfer.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// TransferJSON sends a file transfer like Transfer and handles the response like DoJSON
+func (c *Client) TransferJSON(req *http.Request, size int64, op string, out any) error {
+	resp, err := c.Transfer(req, size)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(resp, op, out)
+}
+
+// TransferTimeout returns how long moving size bytes may take: the overall timeout of
+// other requests, plus the time to move the bytes at MinTransferRate. Without an
+// overall timeout there is no limit (0).
+func TransferTimeout(timeout time.Duration, size int64) time.Duration {
+	if timeout <= 0 {
+		return 0
+	}
+	return timeout + time.Duration(size)*time.Second/MinTransferRate
+}
+
+// cancelOnClose releases a transfer's deadline once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// decodeJSON closes a response, turning a non-2xx status into an *HTTPError describing
+// op and decoding a JSON body into out when out is non-nil
+func decodeJSON(resp *http.Response, op string, out any) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return NewHTTPError(resp, op)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to %s: invalid response: %w", op, err)
+	}
+	return nil
+}
+
+// GetJSON fetches a URL and decodes the JSON response into out
+func (c *Client) GetJSON(url, op string, out any) error {
+	req, err := c.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	return c.DoJSON(req, op, out)
+}
+
+// SendJSON sends body as JSON and decodes the JSON response into out when out is non-nil
+func (c *Client) SendJSON(method, url, op string, body, out any) error {
+	req, err := c.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	return c.DoJSON(req, op, out)
+}
+
+// WithQuery appends encoded query parameters to a URL
+func WithQuery(rawURL string, params url.Values) string {
+	if len(params) == 0 {
+		return rawURL
+	}
+	if strings.Contains(rawURL, "?") {
+		return rawURL + "&" + params.Encode()
+	}
+	return rawURL + "?" + params.Encode()
+}
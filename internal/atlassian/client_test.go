@@ -0,0 +1,53 @@
+package atlassian
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransferOutlastsClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	opts := DefaultClientOptions()
+	opts.Timeout = 100 * time.Millisecond
+	client := NewClient(Credentials{Site: srv.URL}, NewHTTPClient(opts))
+
+	// Regular requests are bound by the overall timeout
+	if err := client.GetJSON(srv.URL, "get", nil); err == nil {
+		t.Fatal("GetJSON outlasted the client timeout")
+	}
+
+	// A transfer gets time for its size: here 100ms plus one second
+	req, err := client.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.TransferJSON(req, MinTransferRate, "download", nil); err != nil {
+		t.Fatalf("TransferJSON: %v", err)
+	}
+}
+
+func TestTransferTimeout(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		size    int64
+		want    time.Duration
+	}{
+		{30 * time.Second, 0, 30 * time.Second},
+		{30 * time.Second, 10 * MinTransferRate, 40 * time.Second},
+		{30 * time.Second, MinTransferRate / 2, 30*time.Second + 500*time.Millisecond},
+		{0, 10 * MinTransferRate, 0}, // No overall timeout, no limit
+	}
+
+	for _, tt := range tests {
+		if got := TransferTimeout(tt.timeout, tt.size); got != tt.want {
+			t.Errorf("TransferTimeout(%v, %d) = %v, want %v", tt.timeout, tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package atlassian
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// Credentials hold connection info for one Atlassian product site
+type Credentials struct {
+	Site        string      // Product base URL, e.g., "https://eso.atlassian.net" or "https://eso.atlassian.net/wiki"
+	Email       string      // e.g., "service@eso.com"; unused for Data Center
+	Token       string      // Atlassian API token, or Personal Access Token for Data Center
+	Deployment  string      // models.DeploymentCloud (default) or models.DeploymentDataCenter
+	AuthType    string      // models.AuthTypeAPIToken (default) or models.AuthTypeOAuth2
+	TokenSource TokenSource // Supplies OAuth 2.0 access tokens when AuthType is oauth2
+}
+
+// TokenSource supplies OAuth 2.0 access tokens, refreshing them before they expire
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// CredentialsFor builds client credentials for one product of a stored workspace.
+// tokenSource is only used by OAuth 2.0 workspaces.
+func CredentialsFor(creds *models.WorkspaceCredentials, product string, tokenSource TokenSource) Credentials {
+	c := Credentials{
+		Site:       creds.ProductURL(product),
+		Email:      creds.Email,
+		Token:      creds.Token,
+		Deployment: creds.DeploymentType(),
+		AuthType:   creds.AuthKind(),
+	}
+	if c.AuthType == models.AuthTypeOAuth2 {
+		c.TokenSource = tokenSource
+	}
+	return c
+}
+
+// IsDataCenter reports whether the credentials are for a self-hosted Data Center instance
+func (c Credentials) IsDataCenter() bool {
+	return c.Deployment == models.DeploymentDataCenter
+}
+
+// Fingerprint identifies a credential set without keeping the token itself
+func (c Credentials) Fingerprint() string {
+	tokenSourceID := ""
+	if fp, ok := c.TokenSource.(interface{ Fingerprint() string }); ok {
+		tokenSourceID = fp.Fingerprint()
+	}
+
+	sum := sha256.Sum256([]byte(c.Deployment + "\x00" + c.AuthType + "\x00" + c.Site + "\x00" +
+		c.Email + "\x00" + c.Token + "\x00" + tokenSourceID))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator sets the credentials of an outgoing request
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Authenticator returns the authentication strategy for the credentials: OAuth 2.0
+// bearer tokens, Personal Access Tokens for Data Center, or Basic email:token for Cloud
+func (c Credentials) Authenticator() Authenticator {
+	switch {
+	case c.AuthType == models.AuthTypeOAuth2:
+		return OAuth2Auth{Source: c.TokenSource}
+	case c.IsDataCenter():
+		return BearerAuth{Token: c.Token}
+	default:
+		return BasicAuth{Email: c.Email, Token: c.Token}
+	}
+}
+
+// BasicAuth authenticates with an Atlassian account email and API token (Cloud)
+type BasicAuth struct {
+	Email string
+	Token string
+}
+
+// Authenticate implements Authenticator
+func (a BasicAuth) Authenticate(req *http.Request) error {
+	encoded := base64.StdEncoding.EncodeToString([]byte(a.Email + ":" + a.Token))
+	req.Header.Set("Authorization", "Basic "+encoded)
+	return nil
+}
+
+// BearerAuth authenticates with a Personal Access Token (Data Center)
+type BearerAuth struct {
+	Token string
+}
+
+// Authenticate implements Authenticator
+func (a BearerAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// OAuth2Auth authenticates with OAuth 2.0 access tokens, fetching a fresh one when needed
+type OAuth2Auth struct {
+	Source TokenSource
+}
+
+// Authenticate implements Authenticator
+func (a OAuth2Auth) Authenticate(req *http.Request) error {
+	if a.Source == nil {
+		return errors.New("OAuth 2.0 workspace has no token source")
+	}
+	token, err := a.Source.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
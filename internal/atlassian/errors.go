@@ -0,0 +1,213 @@
+package atlassian
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// maxErrorBody bounds how much of an error response is kept
+const maxErrorBody = 4096
+
+// HTTPError is an Atlassian response with an unexpected status code
+type HTTPError struct {
+	Op          string // What was attempted, e.g., "get issue PROJ-1"
+	StatusCode  int
+	Code        string            // models.ErrCode* classification of the status
+	Messages    []string          // Error messages parsed from the body
+	FieldErrors map[string]string // Validation errors by field name
+	Body        string            // Response body, truncated; only reported when nothing could be parsed
+	RetryAfter  time.Duration     // How long Atlassian asked to wait, for 429 and 503 responses
+}
+
+// Error implements error
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("failed to %s (status %d)", e.Op, e.StatusCode)
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %s", e.RetryAfter)
+	}
+
+	var reasons []string
+	reasons = append(reasons, e.Messages...)
+	for _, field := range e.fieldNames() {
+		reasons = append(reasons, fmt.Sprintf("field '%s': %s", field, e.FieldErrors[field]))
+	}
+	if len(reasons) == 0 && e.Body != "" && !strings.HasPrefix(e.Body, "<") {
+		reasons = append(reasons, e.Body)
+	}
+	if len(reasons) > 0 {
+		msg += ": " + strings.Join(reasons, "; ")
+	}
+	return msg
+}
+
+// Details returns the error as ErrorInfo.Details
+func (e *HTTPError) Details() *models.APIErrorDetails {
+	return &models.APIErrorDetails{
+		Operation:         e.Op,
+		Status:            e.StatusCode,
+		Messages:          e.Messages,
+		FieldErrors:       e.FieldErrors,
+		RetryAfterSeconds: int(e.RetryAfter.Round(time.Second) / time.Second),
+	}
+}
+
+// fieldNames returns the fields with errors in a stable order
+func (e *HTTPError) fieldNames() []string {
+	names := make([]string, 0, len(e.FieldErrors))
+	for name := range e.FieldErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewHTTPError describes why op failed from the response status, headers and
+// body; the caller still closes the body
+func NewHTTPError(resp *http.Response, op string) *HTTPError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+	err := &HTTPError{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Code:       CodeForStatus(resp.StatusCode),
+		Body:       strings.TrimSpace(string(body)),
+	}
+	err.Messages, err.FieldErrors = parseErrorBody(body)
+	if retryAfter, ok := RateLimitDelay(resp.Header); ok {
+		err.RetryAfter = retryAfter
+	}
+	return err
+}
+
+// errorBody covers the error payloads of Jira ({"errorMessages": [...], "errors": {"field": "..."}}),
+// Confluence v1 ({"message": "...", "data": {"errors": [...]}}) and the v2 APIs
+// ({"errors": [{"title": "...", "detail": "..."}]})
+type errorBody struct {
+	ErrorMessages []string        `json:"errorMessages"`
+	Errors        json.RawMessage `json:"errors"`
+	Message       string          `json:"message"`
+	Data          struct {
+		Errors []errorItem `json:"errors"`
+	} `json:"data"`
+}
+
+// errorItem is one entry of an error list
+type errorItem struct {
+	Title   string          `json:"title"`
+	Detail  string          `json:"detail"`
+	Message json.RawMessage `json:"message"` // A string, or {"key": "...", "translation": "..."}
+}
+
+// text returns the most specific description of an error item
+func (i errorItem) text() string {
+	var message string
+	if json.Unmarshal(i.Message, &message) != nil {
+		var translated struct {
+			Key         string `json:"key"`
+			Translation string `json:"translation"`
+		}
+		if json.Unmarshal(i.Message, &translated) == nil {
+			message = translated.Translation
+			if message == "" {
+				message = translated.Key
+			}
+		}
+	}
+
+	switch {
+	case i.Detail != "":
+		return i.Detail
+	case message != "":
+		return message
+	default:
+		return i.Title
+	}
+}
+
+// parseErrorBody extracts messages and field errors from an Atlassian error payload
+func parseErrorBody(body []byte) ([]string, map[string]string) {
+	var parsed errorBody
+	if json.Unmarshal(body, &parsed) != nil {
+		return nil, nil
+	}
+
+	var messages []string
+	add := func(msg string) {
+		if msg = strings.TrimSpace(msg); msg != "" {
+			messages = append(messages, msg)
+		}
+	}
+
+	for _, msg := range parsed.ErrorMessages {
+		add(msg)
+	}
+
+	// Jira keys validation errors by field; other APIs list them
+	var fields map[string]string
+	if json.Unmarshal(parsed.Errors, &fields) != nil || len(fields) == 0 {
+		fields = nil
+		var items []errorItem
+		if json.Unmarshal(parsed.Errors, &items) == nil {
+			for _, item := range items {
+				add(item.text())
+			}
+		}
+	}
+
+	for _, item := range parsed.Data.Errors {
+		add(item.text())
+	}
+	if len(messages) == 0 {
+		add(parsed.Message)
+	}
+
+	return messages, fields
+}
+
+// CodeForStatus maps an HTTP status code to a models.ErrCode* value
+func CodeForStatus(code int) string {
+	switch code {
+	case http.StatusUnauthorized:
+		return models.ErrCodeAuthFailed
+	case http.StatusForbidden:
+		return models.ErrCodeForbidden
+	case http.StatusNotFound:
+		return models.ErrCodeNotFound
+	case http.StatusConflict:
+		return models.ErrCodeConflict
+	case http.StatusTooManyRequests:
+		return models.ErrCodeRateLimited
+	case http.StatusBadRequest:
+		return models.ErrCodeInvalidRequest
+	default:
+		return models.ErrCodeAPIError
+	}
+}
+
+// ErrorCode returns the models.ErrCode* value for an error returned by a client,
+// falling back to ErrCodeAPIError for errors that carry no status
+func ErrorCode(err error) string {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return models.ErrCodeAPIError
+}
+
+// ErrorResponse creates a service error response for an error returned by a client,
+// carrying the parsed Atlassian error in ErrorInfo.Details
+func ErrorResponse(err error, requestID string) map[string]interface{} {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return models.ErrorResponseWithDetails(httpErr.Code, err.Error(), httpErr.Details(), requestID)
+	}
+	return models.ErrorResponse(models.ErrCodeAPIError, err.Error(), requestID)
+}
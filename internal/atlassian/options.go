@@ -0,0 +1,156 @@
+package atlassian
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent identifies the server to Atlassian
+const DefaultUserAgent = "trilix-atlassian-mcp/1.0"
+
+// ClientOptions tunes the HTTP behavior shared by all clients
+type ClientOptions struct {
+	Timeout             time.Duration // Overall per-request timeout, including reading the body and retries; file transfers get more time for their size
+	DialTimeout         time.Duration // TCP connect timeout
+	TLSHandshakeTimeout time.Duration
+	IdleConnTimeout     time.Duration // How long idle keep-alive connections are kept
+	MaxIdleConnsPerHost int           // Keep-alive connections kept per Atlassian site
+	Retry               RetryPolicy
+	UserAgent           string
+	Logger              *log.Logger // Logs every request when set
+}
+
+// DefaultClientOptions returns the options used when nothing is configured
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		Timeout:             30 * time.Second,
+		DialTimeout:         10 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConnsPerHost: 10,
+		Retry:               DefaultRetryPolicy(),
+		UserAgent:           DefaultUserAgent,
+	}
+}
+
+// ClientOptionsFromEnv reads ATLASSIAN_HTTP_TIMEOUT, ATLASSIAN_DIAL_TIMEOUT,
+// ATLASSIAN_IDLE_CONN_TIMEOUT, ATLASSIAN_MAX_IDLE_CONNS_PER_HOST, ATLASSIAN_MAX_RETRIES,
+// ATLASSIAN_MAX_RETRY_DELAY and ATLASSIAN_USER_AGENT on top of the defaults.
+// Requests are logged to stderr when LOG_LEVEL is "debug".
+func ClientOptionsFromEnv() (ClientOptions, error) {
+	opts := DefaultClientOptions()
+
+	durations := map[string]*time.Duration{
+		"ATLASSIAN_HTTP_TIMEOUT":      &opts.Timeout,
+		"ATLASSIAN_DIAL_TIMEOUT":      &opts.DialTimeout,
+		"ATLASSIAN_IDLE_CONN_TIMEOUT": &opts.IdleConnTimeout,
+		"ATLASSIAN_MAX_RETRY_DELAY":   &opts.Retry.MaxDelay,
+	}
+	for name, target := range durations {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*target = d
+		}
+	}
+
+	integers := map[string]*int{
+		"ATLASSIAN_MAX_IDLE_CONNS_PER_HOST": &opts.MaxIdleConnsPerHost,
+		"ATLASSIAN_MAX_RETRIES":             &opts.Retry.MaxRetries,
+	}
+	for name, target := range integers {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*target = n
+		}
+	}
+
+	if v := os.Getenv("ATLASSIAN_USER_AGENT"); v != "" {
+		opts.UserAgent = v
+	}
+	if strings.EqualFold(os.Getenv("LOG_LEVEL"), "debug") {
+		// stdout may carry MCP messages, so logs go to stderr
+		opts.Logger = log.New(os.Stderr, "atlassian: ", log.LstdFlags)
+	}
+
+	return opts, nil
+}
+
+// NewHTTPClient creates an HTTP client with a tuned keep-alive transport, retries,
+// the user agent and optional request logging
+func NewHTTPClient(opts ClientOptions) *http.Client {
+	var transport http.RoundTripper = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   opts.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	// Logging sits below retries so every attempt is logged
+	if opts.Logger != nil {
+		transport = &loggingTransport{base: transport, logger: opts.Logger}
+	}
+	transport = NewRetryTransport(transport, opts.Retry)
+	if opts.UserAgent != "" {
+		transport = &userAgentTransport{base: transport, userAgent: opts.UserAgent}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+	}
+}
+
+// userAgentTransport sets the User-Agent header of requests that have none
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// loggingTransport logs the method, host, path, status and latency of each request.
+// Query strings are left out since they may carry search terms.
+type loggingTransport struct {
+	base   http.RoundTripper
+	logger *log.Logger
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		t.logger.Printf("%s %s%s failed after %s: %v", req.Method, req.URL.Host, req.URL.Path, latency, err)
+	} else {
+		t.logger.Printf("%s %s%s %d %s", req.Method, req.URL.Host, req.URL.Path, resp.StatusCode, latency)
+	}
+	return resp, err
+}
@@ -0,0 +1,146 @@
+package atlassian
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CollectOffset walks an offset-paginated collection (start/limit or startAt/maxResults).
+// fetch is called with the offset and page size of each page and returns how many items
+// it received and whether that was the last page. Paging stops at the last page, at a
+// short page, or once max items were received (max <= 0 means no cap).
+func CollectOffset(pageSize, max int, fetch func(start, limit int) (received int, last bool, err error)) error {
+	start := 0
+	for {
+		limit := pageSize
+		if max > 0 && max-start < limit {
+			limit = max - start
+		}
+		if limit <= 0 {
+			return nil
+		}
+
+		received, last, err := fetch(start, limit)
+		if err != nil {
+			return err
+		}
+		start += received
+		if last || received < limit {
+			return nil
+		}
+	}
+}
+
+// MaxCollected caps how many items a fetch-all listing gathers, however many are asked for
+const MaxCollected = 1000
+
+// Cursor is the position of the next page of a listing. Tools see it only encoded,
+// as an opaque string.
+type Cursor struct {
+	Start int    `json:"s,omitempty"` // Offset of the next page
+	Next  string `json:"n,omitempty"` // Path of the next page from the response's _links.next
+	Token string `json:"t,omitempty"` // Page token of APIs paginated by token: Jira's nextPageToken, Confluence v2's cursor
+}
+
+// Encode returns the cursor as an opaque string
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor from Encode. An empty string is the first page.
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	if s == "" {
+		return c, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Start < 0 {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	// The next page must be fetched from the site the client is bound to
+	if c.Next != "" && (!strings.HasPrefix(c.Next, "/") || strings.HasPrefix(c.Next, "//")) {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// NextURL returns the URL of the page at cursor.Next on site, with its limit replaced
+func (c Cursor) NextURL(site string, limit int) (string, error) {
+	u, err := url.Parse(site + c.Next)
+	if err != nil {
+		return "", fmt.Errorf("invalid cursor")
+	}
+	q := u.Query()
+	q.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// PageRequest selects results of a listing: one page from Cursor, or with All,
+// every page from Cursor until Limit items were received
+type PageRequest struct {
+	Cursor Cursor
+	Limit  int
+	All    bool
+}
+
+// PageRequestFromParams reads the cursor, limit and fetch_all tool params. Fetch-all
+// limits are capped at MaxCollected; single pages are capped at the page size by Collect.
+func PageRequestFromParams(params map[string]any, defaultLimit int) (PageRequest, error) {
+	page := PageRequest{Limit: defaultLimit}
+	if l, ok := params["limit"].(float64); ok && l > 0 {
+		page.Limit = int(l)
+	}
+	page.All, _ = params["fetch_all"].(bool)
+	if page.All && (page.Limit > MaxCollected || params["limit"] == nil) {
+		page.Limit = MaxCollected
+	}
+
+	cursor, _ := params["cursor"].(string)
+	c, err := DecodeCursor(cursor)
+	if err != nil {
+		return page, err
+	}
+	page.Cursor = c
+	return page, nil
+}
+
+// Collect fetches the pages a request selects. fetch gets the cursor and size of each
+// page and returns how many items it received and the cursor of the page after it,
+// nil after the last page. Pages hold at most pageSize items, however large a single
+// page the request asks for. Collect returns the cursor to continue from, nil when the
+// listing is exhausted.
+func (p PageRequest) Collect(pageSize int, fetch func(cursor Cursor, limit int) (received int, next *Cursor, err error)) (*Cursor, error) {
+	cursor := p.Cursor
+	received := 0
+	for {
+		limit := min(pageSize, p.Limit-received)
+
+		n, next, err := fetch(cursor, limit)
+		if err != nil {
+			return nil, err
+		}
+		received += n
+		if !p.All || next == nil || n == 0 || received >= p.Limit {
+			return next, nil
+		}
+		cursor = *next
+	}
+}
+
+// NextCursor returns the encoded cursor, or "" when there is none
+func NextCursor(c *Cursor) string {
+	if c == nil {
+		return ""
+	}
+	return c.Encode()
+}
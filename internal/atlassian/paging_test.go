@@ -0,0 +1,65 @@
+package atlassian
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pages serves a listing of total items in pages, recording the limit of each request
+type pages struct {
+	total  int
+	limits []int
+}
+
+func (p *pages) fetch(cursor Cursor, limit int) (int, *Cursor, error) {
+	p.limits = append(p.limits, limit)
+	n := min(limit, p.total-cursor.Start)
+	if cursor.Start+n >= p.total {
+		return n, nil, nil
+	}
+	return n, &Cursor{Start: cursor.Start + n}, nil
+}
+
+func TestCollectCapsSinglePagesAtPageSize(t *testing.T) {
+	page, err := PageRequestFromParams(map[string]any{"limit": float64(5000)}, 50)
+	if err != nil {
+		t.Fatalf("PageRequestFromParams: %v", err)
+	}
+
+	src := &pages{total: 300}
+	next, err := page.Collect(100, src.fetch)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if !reflect.DeepEqual(src.limits, []int{100}) {
+		t.Fatalf("requested limits %v, want one page of 100", src.limits)
+	}
+	if next == nil || next.Start != 100 {
+		t.Fatalf("next cursor = %+v, want start 100", next)
+	}
+}
+
+func TestCollectFetchAll(t *testing.T) {
+	page, err := PageRequestFromParams(map[string]any{"limit": float64(250), "fetch_all": true}, 50)
+	if err != nil {
+		t.Fatalf("PageRequestFromParams: %v", err)
+	}
+
+	src := &pages{total: 300}
+	next, err := page.Collect(100, src.fetch)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if !reflect.DeepEqual(src.limits, []int{100, 100, 50}) {
+		t.Fatalf("requested limits %v, want 100, 100 and 50", src.limits)
+	}
+	if next == nil || next.Start != 250 {
+		t.Fatalf("next cursor = %+v, want start 250", next)
+	}
+
+	// Without a limit, fetch-all stops at MaxCollected
+	page, _ = PageRequestFromParams(map[string]any{"fetch_all": true}, 50)
+	if page.Limit != MaxCollected {
+		t.Fatalf("fetch-all limit = %d, want %d", page.Limit, MaxCollected)
+	}
+}
@@ -0,0 +1,72 @@
+package atlassian
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Pool reuses product clients per user/workspace. All clients share one HTTP
+// client, so repeated calls to the same Atlassian site reuse keep-alive connections.
+type Pool[C any] struct {
+	httpClient *http.Client
+	newClient  func(Credentials, *http.Client) C
+	mu         sync.Mutex
+	clients    map[string]*pooledClient[C]
+}
+
+// pooledClient remembers which credentials a client was built with
+type pooledClient[C any] struct {
+	fingerprint string
+	client      C
+}
+
+// NewPool creates a client pool; newClient builds a product client on the shared HTTP client
+func NewPool[C any](opts ClientOptions, newClient func(Credentials, *http.Client) C) *Pool[C] {
+	return &Pool[C]{
+		httpClient: NewHTTPClient(opts),
+		newClient:  newClient,
+		clients:    make(map[string]*pooledClient[C]),
+	}
+}
+
+// Get returns the pooled client for a user/workspace, replacing it when the
+// credentials no longer match the ones it was built with
+func (p *Pool[C]) Get(userID, workspaceID string, creds Credentials) C {
+	key := userID + "\x00" + workspaceID
+	fingerprint := creds.Fingerprint()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pc, ok := p.clients[key]; ok && pc.fingerprint == fingerprint {
+		return pc.client
+	}
+
+	client := p.newClient(creds, p.httpClient)
+	p.clients[key] = &pooledClient[C]{
+		fingerprint: fingerprint,
+		client:      client,
+	}
+	return client
+}
+
+// NewClient creates a client on the shared HTTP client without caching it,
+// e.g. for credentials that have not been saved yet
+func (p *Pool[C]) NewClient(creds Credentials) C {
+	return p.newClient(creds, p.httpClient)
+}
+
+// Invalidate drops the pooled client for a user/workspace
+func (p *Pool[C]) Invalidate(userID, workspaceID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clients, userID+"\x00"+workspaceID)
+}
+
+// Close drops all clients and closes idle connections
+func (p *Pool[C]) Close() {
+	p.mu.Lock()
+	p.clients = make(map[string]*pooledClient[C])
+	p.mu.Unlock()
+	p.httpClient.CloseIdleConnections()
+}
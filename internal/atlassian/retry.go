@@ -0,0 +1,271 @@
+// Package atlassian holds the HTTP behavior shared by the Jira and Confluence clients
+package atlassian
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how throttled and failed requests are retried
+type RetryPolicy struct {
+	MaxRetries int           // Extra attempts after the first; 0 disables retries
+	BaseDelay  time.Duration // Backoff before the first retry when the response gives no Retry-After
+	MaxDelay   time.Duration // Longest wait before a retry; a longer Retry-After fails the request instead
+}
+
+// DefaultRetryPolicy returns the policy used when nothing is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   10 * time.Second,
+	}
+}
+
+type contextKey int
+
+const (
+	idempotentKey contextKey = iota
+	noRetryKey
+)
+
+// Idempotent marks a request whose method is not idempotent (e.g., a POST search) as safe to retry
+func Idempotent(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), idempotentKey, true))
+}
+
+// NoRetry marks a request that must be attempted only once, e.g., a health probe
+// that should report throttling rather than wait it out
+func NoRetry(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), noRetryKey, true))
+}
+
+// RetryTransport retries idempotent requests that were throttled (429), hit a transient
+// server error (502, 503, 504) or failed in transit. It waits for the delay the response
+// asks for (Retry-After, or X-RateLimit-Reset once X-RateLimit-Remaining reaches 0), or
+// backs off exponentially with jitter when there is none.
+//
+// A response reporting an exhausted rate limit also pauses later requests to the same
+// host until the limit resets, so they are not sent only to be throttled.
+type RetryTransport struct {
+	Base   http.RoundTripper
+	Policy RetryPolicy
+
+	mu          sync.Mutex
+	pausedUntil map[string]time.Time // Rate limit resets by host
+}
+
+// NewRetryTransport wraps a transport; a nil base uses http.DefaultTransport
+func NewRetryTransport(base http.RoundTripper, policy RetryPolicy) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryTransport{
+		Base:   base,
+		Policy: policy,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if noRetry, _ := req.Context().Value(noRetryKey).(bool); noRetry {
+		// Probes report throttling instead of waiting it out
+		return t.Base.RoundTrip(req)
+	}
+
+	if err := t.waitForReset(req); err != nil {
+		return nil, err
+	}
+
+	if !t.retryable(req) {
+		resp, err := t.Base.RoundTrip(req)
+		t.observe(req, resp)
+		return resp, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.Base.RoundTrip(req)
+		t.observe(req, resp)
+		if attempt >= t.Policy.MaxRetries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if wait, ok := RateLimitDelay(resp.Header); ok {
+				if wait > t.Policy.MaxDelay {
+					// Waiting that long would outlast the caller; let it see the 429
+					return resp, nil
+				}
+				delay = wait
+			}
+		}
+
+		// The next attempt needs a fresh copy of the body
+		hasBody := req.Body != nil && req.Body != http.NoBody
+		if hasBody && req.GetBody == nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if hasBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// observe remembers when a host's exhausted rate limit resets
+func (t *RetryTransport) observe(req *http.Request, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining > 0 {
+		return
+	}
+	reset, ok := RateLimitReset(resp.Header)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pausedUntil == nil {
+		t.pausedUntil = make(map[string]time.Time)
+	}
+	t.pausedUntil[req.URL.Host] = reset
+}
+
+// waitForReset holds a request until its host's exhausted rate limit resets. Pauses
+// longer than MaxDelay are not waited out; the request goes ahead and may be throttled.
+func (t *RetryTransport) waitForReset(req *http.Request) error {
+	t.mu.Lock()
+	until, ok := t.pausedUntil[req.URL.Host]
+	if ok && !time.Now().Before(until) {
+		delete(t.pausedUntil, req.URL.Host)
+	}
+	t.mu.Unlock()
+
+	delay := time.Until(until)
+	if !ok || delay <= 0 || delay > t.Policy.MaxDelay {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryable reports whether a request may be sent more than once
+func (t *RetryTransport) retryable(req *http.Request) bool {
+	if t.Policy.MaxRetries <= 0 {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	idempotent, _ := req.Context().Value(idempotentKey).(bool)
+	return idempotent
+}
+
+// backoff returns the jittered exponential delay before a retry
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	delay := t.Policy.BaseDelay << attempt
+	if delay <= 0 || delay > t.Policy.MaxDelay {
+		delay = t.Policy.MaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// shouldRetry reports whether an attempt failed in a way another attempt may fix
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RateLimitDelay returns how long a response asks clients to wait: its Retry-After, or
+// when X-RateLimit-Remaining is 0, the time until X-RateLimit-Reset
+func RateLimitDelay(header http.Header) (time.Duration, bool) {
+	if retryAfter, ok := RetryAfter(header); ok {
+		return retryAfter, true
+	}
+
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining > 0 {
+		return 0, false
+	}
+	reset, ok := RateLimitReset(header)
+	if !ok {
+		return 0, false
+	}
+	return max(time.Until(reset), 0), true
+}
+
+// RateLimitReset reads the X-RateLimit-Reset header, which Atlassian sends as an ISO 8601
+// time; Unix timestamps in seconds are accepted too
+func RateLimitReset(header http.Header) (time.Time, bool) {
+	v := header.Get("X-RateLimit-Reset")
+	if v == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil && seconds > 0 {
+		return time.Unix(seconds, 0), true
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00"} {
+		if when, err := time.Parse(layout, v); err == nil {
+			return when, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// RetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func RetryAfter(header http.Header) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		delay := time.Until(when)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
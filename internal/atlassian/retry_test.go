@@ -0,0 +1,291 @@
+package atlassian
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// fakeAtlassian answers each request with the next scripted response and records
+// what it received
+type fakeAtlassian struct {
+	mu        sync.Mutex
+	responses []fakeResponse
+	requests  []recordedRequest
+}
+
+type fakeResponse struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+type recordedRequest struct {
+	at   time.Time
+	body string
+}
+
+func newFakeAtlassian(t *testing.T, responses ...fakeResponse) (*fakeAtlassian, *httptest.Server) {
+	f := &fakeAtlassian{responses: responses}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeAtlassian) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, recordedRequest{at: time.Now(), body: string(body)})
+	resp := fakeResponse{status: http.StatusOK, body: `{"ok":true}`}
+	if len(f.responses) > 0 {
+		resp, f.responses = f.responses[0], f.responses[1:]
+	}
+	f.mu.Unlock()
+
+	for k, v := range resp.header {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.status)
+	io.WriteString(w, resp.body)
+}
+
+func (f *fakeAtlassian) received() []recordedRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]recordedRequest(nil), f.requests...)
+}
+
+// newTestHTTPClient retries quickly so tests do not wait on the default backoff
+func newTestHTTPClient() *http.Client {
+	opts := DefaultClientOptions()
+	opts.Retry = RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+	return NewHTTPClient(opts)
+}
+
+func TestRetryTransportRetriesThrottledAndUnavailable(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header map[string]string
+	}{
+		{"429 with Retry-After", http.StatusTooManyRequests, map[string]string{"Retry-After": "0"}},
+		{"429 without Retry-After", http.StatusTooManyRequests, nil},
+		{"503", http.StatusServiceUnavailable, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, srv := newFakeAtlassian(t, fakeResponse{status: tt.status, header: tt.header, body: `{"message":"slow down"}`})
+			client := NewClient(Credentials{Site: srv.URL}, newTestHTTPClient())
+
+			var out struct{ OK bool }
+			if err := client.GetJSON(srv.URL+"/rest/api/3/myself", "get user", &out); err != nil {
+				t.Fatalf("GetJSON: %v", err)
+			}
+			if !out.OK {
+				t.Fatal("GetJSON did not decode the retried response")
+			}
+			if n := len(fake.received()); n != 2 {
+				t.Fatalf("server received %d requests, want 2", n)
+			}
+		})
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	unavailable := fakeResponse{status: http.StatusServiceUnavailable, body: `{"message":"maintenance"}`}
+	fake, srv := newFakeAtlassian(t, unavailable, unavailable, unavailable, unavailable)
+	client := NewClient(Credentials{Site: srv.URL}, newTestHTTPClient())
+
+	err := client.GetJSON(srv.URL+"/rest/api/3/myself", "get user", nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("GetJSON = %v, want a 503 HTTPError", err)
+	}
+	if n := len(fake.received()); n != 3 {
+		t.Fatalf("server received %d requests, want the first attempt and 2 retries", n)
+	}
+}
+
+func TestRetryTransportWaitsForRateLimitReset(t *testing.T) {
+	reset := time.Now().Add(300 * time.Millisecond)
+	fake, srv := newFakeAtlassian(t, fakeResponse{
+		status: http.StatusTooManyRequests,
+		header: map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     reset.UTC().Format(time.RFC3339Nano),
+		},
+	})
+	client := NewClient(Credentials{Site: srv.URL}, newTestHTTPClient())
+
+	if err := client.GetJSON(srv.URL+"/rest/api/3/myself", "get user", nil); err != nil {
+		t.Fatalf("GetJSON: %v", err)
+	}
+	requests := fake.received()
+	if len(requests) != 2 {
+		t.Fatalf("server received %d requests, want 2", len(requests))
+	}
+	if requests[1].at.Before(reset) {
+		t.Fatalf("retried %v before the rate limit reset", reset.Sub(requests[1].at))
+	}
+}
+
+func TestRetryTransportPausesHostUntilReset(t *testing.T) {
+	// A successful response that used up the limit holds back the next request,
+	// even one that is never retried
+	reset := time.Now().Add(300 * time.Millisecond)
+	fake, srv := newFakeAtlassian(t, fakeResponse{
+		status: http.StatusOK,
+		header: map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(reset.Add(time.Second).Unix(), 10),
+		},
+		body: `{}`,
+	})
+	client := NewClient(Credentials{Site: srv.URL}, newTestHTTPClient())
+
+	if err := client.GetJSON(srv.URL+"/rest/api/3/myself", "get user", nil); err != nil {
+		t.Fatalf("GetJSON: %v", err)
+	}
+	if err := client.SendJSON(http.MethodPost, srv.URL+"/rest/api/3/issue", "create issue", map[string]string{}, nil); err != nil {
+		t.Fatalf("SendJSON: %v", err)
+	}
+
+	requests := fake.received()
+	if len(requests) != 2 {
+		t.Fatalf("server received %d requests, want 2", len(requests))
+	}
+	if requests[1].at.Before(reset) {
+		t.Fatal("second request was sent before the rate limit reset")
+	}
+}
+
+func TestRetryTransportRetriesOnlyIdempotentRequests(t *testing.T) {
+	throttled := fakeResponse{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "0"}}
+
+	t.Run("POST", func(t *testing.T) {
+		fake, srv := newFakeAtlassian(t, throttled)
+		client := NewClient(Credentials{Site: srv.URL}, newTestHTTPClient())
+
+		err := client.SendJSON(http.MethodPost, srv.URL+"/rest/api/3/issue", "create issue", map[string]string{"summary": "x"}, nil)
+		if ErrorCode(err) != models.ErrCodeRateLimited {
+			t.Fatalf("SendJSON = %v, want a rate limit error", err)
+		}
+		if n := len(fake.received()); n != 1 {
+			t.Fatalf("server received %d requests, want 1", n)
+		}
+	})
+
+	t.Run("idempotent POST", func(t *testing.T) {
+		fake, srv := newFakeAtlassian(t, throttled)
+		client := NewClient(Credentials{Site: srv.URL}, newTestHTTPClient())
+
+		req, err := client.NewRequest(http.MethodPost, srv.URL+"/rest/api/3/search/jql", map[string]string{"jql": "project = X"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.DoJSON(Idempotent(req), "search issues", nil); err != nil {
+			t.Fatalf("DoJSON: %v", err)
+		}
+
+		requests := fake.received()
+		if len(requests) != 2 {
+			t.Fatalf("server received %d requests, want 2", len(requests))
+		}
+		if requests[1].body != requests[0].body || !bytes.Contains([]byte(requests[1].body), []byte("project = X")) {
+			t.Fatalf("retry sent body %q, want the original %q", requests[1].body, requests[0].body)
+		}
+	})
+}
+
+func TestRetryTransportReportsLongWaits(t *testing.T) {
+	fake, srv := newFakeAtlassian(t, fakeResponse{
+		status: http.StatusTooManyRequests,
+		header: map[string]string{"Retry-After": "60"},
+	})
+	client := NewClient(Credentials{Site: srv.URL}, newTestHTTPClient())
+
+	err := client.GetJSON(srv.URL+"/rest/api/3/myself", "get user", nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Code != models.ErrCodeRateLimited {
+		t.Fatalf("GetJSON = %v, want a rate limit error", err)
+	}
+	if httpErr.RetryAfter != 60*time.Second {
+		t.Fatalf("RetryAfter = %v, want 60s", httpErr.RetryAfter)
+	}
+	if n := len(fake.received()); n != 1 {
+		t.Fatalf("server received %d requests, want 1", n)
+	}
+}
+
+func TestErrorCodesFromFakeServer(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusUnauthorized, models.ErrCodeAuthFailed},
+		{http.StatusNotFound, models.ErrCodeNotFound},
+		{http.StatusTooManyRequests, models.ErrCodeRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			_, srv := newFakeAtlassian(t, fakeResponse{status: tt.status, header: map[string]string{"Retry-After": "3600"}})
+			client := NewClient(Credentials{Site: srv.URL}, newTestHTTPClient())
+
+			err := client.GetJSON(srv.URL+"/rest/api/3/issue/X-1", "get issue X-1", nil)
+			if got := ErrorCode(err); got != tt.want {
+				t.Fatalf("ErrorCode(%v) = %s, want %s", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitDelay(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		header http.Header
+		ok     bool
+		min    time.Duration
+		max    time.Duration
+	}{
+		{"none", http.Header{}, false, 0, 0},
+		{"Retry-After", http.Header{"Retry-After": {"5"}}, true, 5 * time.Second, 5 * time.Second},
+		{"remaining left", http.Header{
+			"X-Ratelimit-Remaining": {"10"},
+			"X-Ratelimit-Reset":     {now.Add(time.Minute).Format(time.RFC3339)},
+		}, false, 0, 0},
+		{"ISO 8601 reset", http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {now.Add(time.Minute).UTC().Format("2006-01-02T15:04Z")},
+		}, true, 0, time.Minute},
+		{"Unix reset", http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {strconv.FormatInt(now.Add(30*time.Second).Unix(), 10)},
+		}, true, 28 * time.Second, 30 * time.Second},
+		{"past reset", http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {now.Add(-time.Minute).Format(time.RFC3339)},
+		}, true, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := RateLimitDelay(tt.header)
+			if ok != tt.ok || got < tt.min || got > tt.max {
+				t.Fatalf("RateLimitDelay = %v, %v; want %v in [%v, %v]", got, ok, tt.ok, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// NewTokenCipherFromEnv creates the token cipher selected by KEY_PROVIDER.
+//
+//   - "passphrase" (default): PBKDF2 scheme keyed by API_KEY_ENCRYPTION_KEY
+//   - "local": envelope encryption with the keyring file at KEY_FILE
+//   - "vault": envelope encryption with a Vault Transit key (VAULT_ADDR,
+//     VAULT_TOKEN or VAULT_TOKEN_FILE, VAULT_NAMESPACE, VAULT_TRANSIT_MOUNT,
+//     VAULT_TRANSIT_KEY)
+//
+// With an envelope provider, API_KEY_ENCRYPTION_KEY is optional and only used to
+// read records written before the switch.
+func NewTokenCipherFromEnv() (TokenCipher, error) {
+	passphrase := os.Getenv("API_KEY_ENCRYPTION_KEY")
+
+	var legacy TokenCipher
+	if passphrase != "" {
+		legacy = NewPassphraseCipher(passphrase)
+	}
+
+	switch provider := os.Getenv("KEY_PROVIDER"); provider {
+	case "", "passphrase":
+		if legacy == nil {
+			return nil, fmt.Errorf("API_KEY_ENCRYPTION_KEY is required when KEY_PROVIDER is passphrase")
+		}
+		return legacy, nil
+
+	case "local":
+		keyFile := os.Getenv("KEY_FILE")
+		if keyFile == "" {
+			return nil, fmt.Errorf("KEY_FILE is required when KEY_PROVIDER is local")
+		}
+		kp, err := NewLocalKeyProvider(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewEnvelopeCipher(kp, legacy), nil
+
+	case "vault":
+		token := os.Getenv("VAULT_TOKEN")
+		if tokenFile := os.Getenv("VAULT_TOKEN_FILE"); tokenFile != "" {
+			data, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read VAULT_TOKEN_FILE: %w", err)
+			}
+			token = strings.TrimSpace(string(data))
+		}
+
+		keyName := os.Getenv("VAULT_TRANSIT_KEY")
+		if keyName == "" {
+			keyName = "trilix-credentials"
+		}
+
+		kp, err := NewVaultTransitProvider(VaultConfig{
+			Address:   os.Getenv("VAULT_ADDR"),
+			Token:     token,
+			Namespace: os.Getenv("VAULT_NAMESPACE"),
+			Mount:     os.Getenv("VAULT_TRANSIT_MOUNT"),
+			KeyName:   keyName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewEnvelopeCipher(kp, legacy), nil
+
+	default:
+		return nil, fmt.Errorf("unknown KEY_PROVIDER: %s", provider)
+	}
+}
@@ -0,0 +1,178 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// envelopePrefix marks ciphertexts produced by EnvelopeCipher.
+// Format: env1.<provider>.<base64url wrapped data key>.<base64 nonce+ciphertext>
+const envelopePrefix = "env1"
+
+// KeyProvider wraps and unwraps per-record data keys with a key-encryption key (KEK)
+// that never leaves the provider
+type KeyProvider interface {
+	// Name identifies the provider inside stored ciphertexts
+	Name() string
+	// WrapKey encrypts a data key with the provider's KEK
+	WrapKey(dataKey []byte) (string, error)
+	// UnwrapKey decrypts a data key previously returned by WrapKey
+	UnwrapKey(wrapped string) ([]byte, error)
+}
+
+// TokenCipher encrypts and decrypts credential secrets for storage
+type TokenCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// PassphraseCipher is the original scheme: AES-256-GCM with a PBKDF2-derived key
+type PassphraseCipher struct {
+	passphrase string
+}
+
+// NewPassphraseCipher creates a cipher using a master passphrase
+func NewPassphraseCipher(passphrase string) *PassphraseCipher {
+	return &PassphraseCipher{passphrase: passphrase}
+}
+
+// Encrypt encrypts plaintext with the passphrase
+func (c *PassphraseCipher) Encrypt(plaintext string) (string, error) {
+	return Encrypt(plaintext, c.passphrase)
+}
+
+// Decrypt decrypts ciphertext with the passphrase
+func (c *PassphraseCipher) Decrypt(ciphertext string) (string, error) {
+	return Decrypt(ciphertext, c.passphrase)
+}
+
+// EnvelopeCipher encrypts every record with a fresh random data key and stores
+// that data key wrapped by a KeyProvider next to the ciphertext
+type EnvelopeCipher struct {
+	provider KeyProvider
+	legacy   TokenCipher // Optional; decrypts records written before envelope encryption
+}
+
+// NewEnvelopeCipher creates an envelope cipher. legacy may be nil; when set it is
+// used to decrypt values that do not carry the envelope prefix.
+func NewEnvelopeCipher(provider KeyProvider, legacy TokenCipher) *EnvelopeCipher {
+	return &EnvelopeCipher{
+		provider: provider,
+		legacy:   legacy,
+	}
+}
+
+// Encrypt encrypts plaintext under a new data key
+func (c *EnvelopeCipher) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, keyLength)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	defer zero(dataKey)
+
+	wrapped, err := c.provider.WrapKey(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key with %s provider: %w", c.provider.Name(), err)
+	}
+
+	header := strings.Join([]string{
+		envelopePrefix,
+		c.provider.Name(),
+		base64.RawURLEncoding.EncodeToString([]byte(wrapped)),
+	}, ".")
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, nonceLength)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	// The header is authenticated so a ciphertext cannot be paired with another data key
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(header))
+
+	return header + "." + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts an envelope ciphertext, falling back to the legacy cipher
+// for values written before envelope encryption was enabled
+func (c *EnvelopeCipher) Decrypt(ciphertext string) (string, error) {
+	if !IsEnvelope(ciphertext) {
+		if c.legacy == nil {
+			return "", errors.New("ciphertext is not envelope-encrypted and no legacy key is configured")
+		}
+		return c.legacy.Decrypt(ciphertext)
+	}
+
+	parts := strings.Split(ciphertext, ".")
+	if len(parts) != 4 {
+		return "", errors.New("malformed envelope ciphertext")
+	}
+
+	if parts[1] != c.provider.Name() {
+		return "", fmt.Errorf("ciphertext was written by the %s key provider, but %s is configured",
+			parts[1], c.provider.Name())
+	}
+
+	wrapped, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < nonceLength {
+		return "", errors.New("ciphertext too short")
+	}
+
+	dataKey, err := c.provider.UnwrapKey(string(wrapped))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key with %s provider: %w", c.provider.Name(), err)
+	}
+	defer zero(dataKey)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	header := strings.Join(parts[:3], ".")
+	plaintext, err := gcm.Open(nil, sealed[:nonceLength], sealed[nonceLength:], []byte(header))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// IsEnvelope reports whether a stored value was produced by EnvelopeCipher
+func IsEnvelope(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, envelopePrefix+".")
+}
+
+// newGCM creates an AES-GCM AEAD for a raw key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// zero overwrites key material in place
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
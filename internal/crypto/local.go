@@ -0,0 +1,133 @@
+package crypto
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LocalKeyProvider wraps data keys with key-encryption keys loaded from a keyring file.
+//
+// The keyring file holds one key per line as "<key-id>:<key>", where the key is
+// 32 bytes encoded as 64 hex characters or standard base64. A bare key without an
+// ID is accepted and gets the ID "default". Blank lines and lines starting with
+// '#' are ignored. The last key in the file is used for wrapping; earlier keys
+// stay available for unwrapping, so keys can be rotated by appending a new line.
+type LocalKeyProvider struct {
+	keys     map[string][]byte
+	activeID string
+}
+
+// NewLocalKeyProvider loads a keyring file
+func NewLocalKeyProvider(path string) (*LocalKeyProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open key file: %w", err)
+	}
+	defer file.Close()
+
+	provider := &LocalKeyProvider{
+		keys: make(map[string][]byte),
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, encoded := "default", line
+		if i := strings.Index(line, ":"); i >= 0 {
+			id, encoded = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+		if id == "" || strings.ContainsAny(id, ".:") {
+			return nil, fmt.Errorf("key file line %d: invalid key id %q", lineNo, id)
+		}
+
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key file line %d: %w", lineNo, err)
+		}
+
+		provider.keys[id] = key
+		provider.activeID = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if provider.activeID == "" {
+		return nil, errors.New("key file contains no keys")
+	}
+
+	return provider, nil
+}
+
+// Name returns the provider name
+func (p *LocalKeyProvider) Name() string {
+	return "local"
+}
+
+// WrapKey encrypts a data key with the active key-encryption key
+func (p *LocalKeyProvider) WrapKey(dataKey []byte) (string, error) {
+	gcm, err := newGCM(p.keys[p.activeID])
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, nonceLength)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, dataKey, []byte(p.activeID))
+	return p.activeID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// UnwrapKey decrypts a data key with the key-encryption key it was wrapped under
+func (p *LocalKeyProvider) UnwrapKey(wrapped string) ([]byte, error) {
+	id, encoded, ok := strings.Cut(wrapped, ":")
+	if !ok {
+		return nil, errors.New("malformed wrapped key")
+	}
+
+	kek, exists := p.keys[id]
+	if !exists {
+		return nil, fmt.Errorf("key %q is not in the keyring", id)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < nonceLength {
+		return nil, errors.New("wrapped key too short")
+	}
+
+	gcm, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Open(nil, sealed[:nonceLength], sealed[nonceLength:], []byte(id))
+}
+
+// decodeKey accepts a 32-byte key as hex or base64
+func decodeKey(encoded string) ([]byte, error) {
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == keyLength {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == keyLength {
+		return key, nil
+	}
+	return nil, fmt.Errorf("key must be %d bytes encoded as hex or base64", keyLength)
+}
@@ -0,0 +1,129 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultConfig configures the HashiCorp Vault Transit key provider
+type VaultConfig struct {
+	Address   string // e.g., "http://127.0.0.1:8200"
+	Token     string // Vault token with encrypt/decrypt permission on the key
+	Namespace string // Optional Vault Enterprise namespace
+	Mount     string // Transit mount path, defaults to "transit"
+	KeyName   string // Transit key name
+}
+
+// VaultTransitProvider wraps data keys with a HashiCorp Vault Transit key, so the
+// key-encryption key never leaves Vault
+type VaultTransitProvider struct {
+	cfg        VaultConfig
+	httpClient *http.Client
+}
+
+// NewVaultTransitProvider creates a Vault Transit key provider
+func NewVaultTransitProvider(cfg VaultConfig) (*VaultTransitProvider, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("vault address is required")
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("vault token is required")
+	}
+	if cfg.KeyName == "" {
+		return nil, errors.New("vault transit key name is required")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = "transit"
+	}
+	cfg.Address = strings.TrimSuffix(cfg.Address, "/")
+	cfg.Mount = strings.Trim(cfg.Mount, "/")
+
+	return &VaultTransitProvider{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *VaultTransitProvider) Name() string {
+	return "vault"
+}
+
+// WrapKey encrypts a data key with the Transit key
+func (p *VaultTransitProvider) WrapKey(dataKey []byte) (string, error) {
+	var result struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+	payload := map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(dataKey),
+	}
+	if err := p.call("encrypt", payload, &result); err != nil {
+		return "", err
+	}
+	if result.Ciphertext == "" {
+		return "", errors.New("vault returned an empty ciphertext")
+	}
+	return result.Ciphertext, nil
+}
+
+// UnwrapKey decrypts a data key with the Transit key
+func (p *VaultTransitProvider) UnwrapKey(wrapped string) ([]byte, error) {
+	var result struct {
+		Plaintext string `json:"plaintext"`
+	}
+	payload := map[string]string{
+		"ciphertext": wrapped,
+	}
+	if err := p.call("decrypt", payload, &result); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(result.Plaintext)
+}
+
+// call invokes a Transit operation and decodes the "data" field of the response
+func (p *VaultTransitProvider) call(operation string, payload any, out any) error {
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", p.cfg.Address, p.cfg.Mount, operation, p.cfg.KeyName)
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(jsonPayload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", p.cfg.Token)
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []string        `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("vault transit %s failed with status %d", operation, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Vault error messages never include the payload, so they are safe to surface
+		return fmt.Errorf("vault transit %s failed with status %d: %s",
+			operation, resp.StatusCode, strings.Join(body.Errors, "; "))
+	}
+
+	return json.Unmarshal(body.Data, out)
+}
@@ -0,0 +1,281 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTransit is a stand-in for a Vault dev server with the Transit engine mounted.
+// It keeps every version of one key and, like Vault, encrypts with the latest version
+// and prefixes ciphertexts with "vault:v<version>:".
+type fakeTransit struct {
+	t        *testing.T
+	token    string
+	key      string
+	mu       sync.Mutex
+	versions [][]byte // versions[0] is v1
+	calls    map[string]int
+}
+
+func newFakeTransit(t *testing.T, token, key string) (*fakeTransit, *httptest.Server) {
+	f := &fakeTransit{t: t, token: token, key: key, calls: map[string]int{}}
+	f.rotate()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+// rotate adds a key version, as POST /v1/transit/keys/<key>/rotate does
+func (f *fakeTransit) rotate() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := make([]byte, keyLength)
+	if _, err := rand.Read(k); err != nil {
+		f.t.Fatal(err)
+	}
+	f.versions = append(f.versions, k)
+}
+
+func (f *fakeTransit) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != f.token {
+		f.fail(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	var op string
+	switch r.URL.Path {
+	case "/v1/transit/encrypt/" + f.key:
+		op = "encrypt"
+	case "/v1/transit/decrypt/" + f.key:
+		op = "decrypt"
+	case "/v1/transit/keys/" + f.key + "/rotate":
+		f.rotate()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		f.fail(w, http.StatusNotFound, "no handler for route")
+		return
+	}
+
+	var req map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.fail(w, http.StatusBadRequest, "invalid JSON")
+		return
+	}
+
+	f.mu.Lock()
+	f.calls[op]++
+	f.mu.Unlock()
+
+	var data map[string]string
+	var err error
+	if op == "encrypt" {
+		data, err = f.encrypt(req["plaintext"])
+	} else {
+		data, err = f.decrypt(req["ciphertext"])
+	}
+	if err != nil {
+		f.fail(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func (f *fakeTransit) encrypt(plaintext string) (map[string]string, error) {
+	raw, err := base64.StdEncoding.DecodeString(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("plaintext is not base64")
+	}
+
+	f.mu.Lock()
+	version := len(f.versions)
+	key := f.versions[version-1]
+	f.mu.Unlock()
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceLength)
+	rand.Read(nonce)
+	sealed := gcm.Seal(nonce, nonce, raw, nil)
+
+	return map[string]string{
+		"ciphertext":  fmt.Sprintf("vault:v%d:%s", version, base64.StdEncoding.EncodeToString(sealed)),
+		"key_version": strconv.Itoa(version),
+	}, nil
+}
+
+func (f *fakeTransit) decrypt(ciphertext string) (map[string]string, error) {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" || !strings.HasPrefix(parts[1], "v") {
+		return nil, fmt.Errorf("invalid ciphertext: no prefix")
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(parts[1], "v"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: bad version")
+	}
+
+	f.mu.Lock()
+	if version < 1 || version > len(f.versions) {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("invalid key version")
+	}
+	key := f.versions[version-1]
+	f.mu.Unlock()
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil || len(sealed) < nonceLength {
+		return nil, fmt.Errorf("invalid ciphertext")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, sealed[:nonceLength], sealed[nonceLength:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("cipher: message authentication failed")
+	}
+	return map[string]string{"plaintext": base64.StdEncoding.EncodeToString(plain)}, nil
+}
+
+func (f *fakeTransit) fail(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"errors": []string{msg}})
+}
+
+func newTestVaultCipher(t *testing.T) (*fakeTransit, *httptest.Server, *EnvelopeCipher) {
+	t.Helper()
+	fake, srv := newFakeTransit(t, "root", "creds")
+	provider, err := NewVaultTransitProvider(VaultConfig{Address: srv.URL + "/", Token: "root", KeyName: "creds"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, srv, NewEnvelopeCipher(provider, nil)
+}
+
+func TestVaultEnvelopeRoundTrip(t *testing.T) {
+	fake, _, c := newTestVaultCipher(t)
+
+	ciphertext, err := c.Encrypt("atlassian-api-token")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !IsEnvelope(ciphertext) || !strings.HasPrefix(ciphertext, "env1.vault.") {
+		t.Fatalf("ciphertext %q is not a vault envelope", ciphertext)
+	}
+	if strings.Contains(ciphertext, "atlassian-api-token") {
+		t.Fatal("ciphertext contains the plaintext")
+	}
+
+	plaintext, err := c.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if plaintext != "atlassian-api-token" {
+		t.Fatalf("Decrypt = %q, want the original token", plaintext)
+	}
+
+	// Each record gets its own data key, so Vault wraps one key per Encrypt
+	again, err := c.Encrypt("atlassian-api-token")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if again == ciphertext {
+		t.Fatal("two encryptions produced the same ciphertext")
+	}
+	if fake.calls["encrypt"] != 2 || fake.calls["decrypt"] != 1 {
+		t.Fatalf("vault calls = %v, want 2 encrypts and 1 decrypt", fake.calls)
+	}
+}
+
+func TestVaultKeyRotation(t *testing.T) {
+	fake, _, c := newTestVaultCipher(t)
+
+	before, err := c.Encrypt("written before rotation")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	fake.rotate()
+
+	after, err := c.Encrypt("written after rotation")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	// The wrapped data key records which Transit key version wrapped it
+	if v := wrappedVersion(t, before); v != "vault:v1" {
+		t.Errorf("record before rotation wrapped with %s, want vault:v1", v)
+	}
+	if v := wrappedVersion(t, after); v != "vault:v2" {
+		t.Errorf("record after rotation wrapped with %s, want vault:v2", v)
+	}
+
+	// Records wrapped by the old version stay readable
+	for ciphertext, want := range map[string]string{before: "written before rotation", after: "written after rotation"} {
+		got, err := c.Decrypt(ciphertext)
+		if err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+		if got != want {
+			t.Errorf("Decrypt = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestVaultTamperedEnvelope(t *testing.T) {
+	_, _, c := newTestVaultCipher(t)
+
+	ciphertext, err := c.Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	// Swapping in another record's wrapped key must fail authentication
+	other, err := c.Encrypt("other secret")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	parts := strings.Split(ciphertext, ".")
+	parts[2] = strings.Split(other, ".")[2]
+	if _, err := c.Decrypt(strings.Join(parts, ".")); err == nil {
+		t.Fatal("Decrypt accepted a ciphertext paired with another data key")
+	}
+}
+
+func TestVaultErrors(t *testing.T) {
+	_, srv := newFakeTransit(t, "root", "creds")
+
+	provider, err := NewVaultTransitProvider(VaultConfig{Address: srv.URL, Token: "wrong", KeyName: "creds"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewEnvelopeCipher(provider, nil).Encrypt("secret")
+	if err == nil || !strings.Contains(err.Error(), "status 403: permission denied") {
+		t.Fatalf("Encrypt with a bad token = %v, want a 403 permission error", err)
+	}
+
+	if _, err := NewVaultTransitProvider(VaultConfig{Address: srv.URL, Token: "root"}); err == nil {
+		t.Fatal("NewVaultTransitProvider accepted a config without a key name")
+	}
+}
+
+// wrappedVersion returns the Transit key version prefix of an envelope's wrapped key
+func wrappedVersion(t *testing.T, ciphertext string) string {
+	t.Helper()
+	wrapped, err := base64.RawURLEncoding.DecodeString(strings.Split(ciphertext, ".")[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.SplitN(string(wrapped), ":", 3)
+	return parts[0] + ":" + parts[1]
+}
@@ -0,0 +1,573 @@
+package markdown
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	whitespacePattern = regexp.MustCompile(`[ \t\r\n\x{00a0}]+`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+	lineStartPattern  = regexp.MustCompile(`^(#{1,6}[ \t]|>|[-+*][ \t]|\d{1,9}[.)][ \t])`)
+	markdownEscaper   = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "[", `\[`, "]", `\]`)
+)
+
+// voidElements are the HTML elements that may appear unclosed. xml.HTMLAutoClose
+// would also match ac:link, since it compares local names only.
+var voidElements = []string{"br", "hr", "img", "col", "area", "input", "wbr"}
+
+// macroAlerts maps Confluence message macros to the GitHub alerts that render alike
+var macroAlerts = map[string]string{
+	"info":    "NOTE",
+	"tip":     "TIP",
+	"note":    "IMPORTANT",
+	"warning": "WARNING",
+}
+
+// blockElements are the storage format elements rendered as separate blocks
+var blockElements = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "table": true, "pre": true, "blockquote": true, "hr": true, "div": true,
+	"ac:task-list": true, "ac:layout": true, "ac:layout-section": true, "ac:layout-cell": true,
+}
+
+// inlineMacros are the macros rendered within the surrounding text
+var inlineMacros = map[string]bool{
+	"status": true, "jira": true, "anchor": true,
+}
+
+// node is an element or, when name is empty, a run of text
+type node struct {
+	name     string
+	attrs    map[string]string
+	children []*node
+	text     string
+}
+
+// FromStorage converts Confluence storage format to Markdown
+func FromStorage(storage string) (string, error) {
+	root, err := parse(storage)
+	if err != nil {
+		return "", err
+	}
+
+	md := blankLinesPattern.ReplaceAllString(renderBlocks(root.children, "\n\n"), "\n\n")
+	return strings.TrimSpace(md), nil
+}
+
+// parse reads storage format into a tree. Storage format is XHTML with the ac: and ri:
+// prefixes undeclared, so the decoder runs in its lenient HTML mode.
+func parse(storage string) (*node, error) {
+	d := xml.NewDecoder(strings.NewReader("<root>" + storage + "</root>"))
+	d.Strict = false
+	d.AutoClose = voidElements
+	d.Entity = xml.HTMLEntity
+
+	var root *node
+	var stack []*node
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse storage format: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: qualifiedName(t.Name), attrs: make(map[string]string)}
+			for _, a := range t.Attr {
+				n.attrs[qualifiedName(a.Name)] = a.Value
+			}
+			if len(stack) == 0 {
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &node{text: string(t)})
+			}
+		}
+	}
+	return root, nil
+}
+
+// qualifiedName restores the prefix of a name such as ac:link
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return strings.ToLower(name.Local)
+	}
+	return name.Space + ":" + name.Local
+}
+
+// child returns the first child element with a name, or nil
+func (n *node) child(name string) *node {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// textContent returns the text of a node and its descendants
+func (n *node) textContent() string {
+	if n.name == "" {
+		return n.text
+	}
+	var b strings.Builder
+	for _, c := range n.children {
+		b.WriteString(c.textContent())
+	}
+	return b.String()
+}
+
+// isBlock reports whether a node is rendered as a separate block
+func (n *node) isBlock() bool {
+	if n.name == "ac:structured-macro" {
+		return !inlineMacros[n.attrs["ac:name"]]
+	}
+	return blockElements[n.name]
+}
+
+// renderBlocks renders nodes as blocks joined by sep. Runs of inline nodes between
+// blocks form paragraphs.
+func renderBlocks(nodes []*node, sep string) string {
+	var parts []string
+	var run []*node
+	flush := func() {
+		if text := paragraphText(run); text != "" {
+			parts = append(parts, text)
+		}
+		run = nil
+	}
+
+	for _, n := range nodes {
+		if !n.isBlock() {
+			run = append(run, n)
+			continue
+		}
+		flush()
+		if text := renderBlock(n); strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	flush()
+
+	return strings.Join(parts, sep)
+}
+
+// renderBlock renders one block element
+func renderBlock(n *node) string {
+	switch n.name {
+	case "p":
+		return paragraphText(n.children)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.TrimSpace(renderInlines(n.children))
+		return strings.Repeat("#", int(n.name[1]-'0')) + " " + strings.ReplaceAll(text, "\\\n", " ")
+	case "hr":
+		return "---"
+	case "ul", "ol":
+		return renderList(n)
+	case "ac:task-list":
+		return renderTasks(n)
+	case "table":
+		return renderTable(n)
+	case "pre":
+		return fence(n.textContent(), "")
+	case "blockquote":
+		return quoteLines(renderBlocks(n.children, "\n\n"))
+	case "ac:structured-macro":
+		return renderMacro(n)
+	default:
+		return renderBlocks(n.children, "\n\n")
+	}
+}
+
+// paragraphText renders inline nodes as a paragraph, escaping what would otherwise
+// start a heading, quote or list
+func paragraphText(nodes []*node) string {
+	text := strings.TrimLeft(renderInlines(nodes), " \n")
+	text = strings.ReplaceAll(text, "\\\n ", "\\\n")
+	for {
+		// Drop line breaks at the end, which Markdown cannot express
+		text = strings.TrimRight(text, " ")
+		if !strings.HasSuffix(text, "\\\n") {
+			break
+		}
+		text = strings.TrimSuffix(text, "\\\n")
+	}
+	if lineStartPattern.MatchString(text) {
+		text = `\` + text
+	}
+	return text
+}
+
+// renderList renders a bullet or numbered list; nested content is indented under its item
+func renderList(n *node) string {
+	var items []string
+	number := 1
+	for _, li := range n.children {
+		if li.name != "li" {
+			continue
+		}
+		marker := "- "
+		if n.name == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		content := renderBlocks(li.children, "\n")
+		items = append(items, marker+indentLines(content, len(marker)))
+	}
+	return strings.Join(items, "\n")
+}
+
+// renderTasks renders a task list as GitHub task list items
+func renderTasks(n *node) string {
+	var items []string
+	for _, task := range n.children {
+		if task.name != "ac:task" {
+			continue
+		}
+		box := "[ ]"
+		if status := task.child("ac:task-status"); status != nil && strings.TrimSpace(status.textContent()) == "complete" {
+			box = "[x]"
+		}
+		body := ""
+		if b := task.child("ac:task-body"); b != nil {
+			body = strings.TrimSpace(renderBlocks(b.children, "\n"))
+		}
+		items = append(items, "- "+box+" "+indentLines(body, 2))
+	}
+	return strings.Join(items, "\n")
+}
+
+// renderTable renders a table as a GitHub table whose header is the first row. Cell
+// content is flattened to one line.
+func renderTable(n *node) string {
+	var rows [][]string
+	var collect func(*node)
+	collect = func(n *node) {
+		for _, c := range n.children {
+			switch c.name {
+			case "tr":
+				var cells []string
+				for _, cell := range c.children {
+					if cell.name == "th" || cell.name == "td" {
+						cells = append(cells, tableCell(cell))
+					}
+				}
+				rows = append(rows, cells)
+			case "thead", "tbody", "tfoot":
+				collect(c)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	var b strings.Builder
+	for r, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if r == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// tableCell renders a cell's content on one line
+func tableCell(cell *node) string {
+	text := strings.TrimSpace(renderBlocks(cell.children, "\n"))
+	text = strings.ReplaceAll(text, "\\\n", "<br>")
+	text = strings.ReplaceAll(text, "\n", "<br>")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// renderMacro renders a block macro: code as a fenced block, message macros as alerts,
+// and other macros as their body
+func renderMacro(n *node) string {
+	name := n.attrs["ac:name"]
+	params := macroParams(n)
+	body := n.child("ac:rich-text-body")
+
+	switch name {
+	case "code", "noformat":
+		code := ""
+		if b := n.child("ac:plain-text-body"); b != nil {
+			code = b.textContent()
+		}
+		return fence(code, params["language"])
+	case "info", "tip", "note", "warning":
+		var content []string
+		if title := params["title"]; title != "" {
+			content = append(content, "**"+markdownEscaper.Replace(title)+"**")
+		}
+		if body != nil {
+			content = append(content, renderBlocks(body.children, "\n\n"))
+		}
+		return "> [!" + macroAlerts[name] + "]\n" + quoteLines(strings.Join(content, "\n\n"))
+	case "panel", "expand":
+		var content []string
+		if title := params["title"]; title != "" {
+			content = append(content, "**"+markdownEscaper.Replace(title)+"**")
+		}
+		if body != nil {
+			content = append(content, renderBlocks(body.children, "\n\n"))
+		}
+		if name == "panel" {
+			return quoteLines(strings.Join(content, "\n\n"))
+		}
+		return strings.Join(content, "\n\n")
+	}
+
+	if body != nil {
+		return renderBlocks(body.children, "\n\n")
+	}
+	if b := n.child("ac:plain-text-body"); b != nil {
+		return fence(b.textContent(), "")
+	}
+	// Nothing to show, e.g., a table of contents; note where it was
+	return "<!-- " + name + " macro -->"
+}
+
+// macroParams returns the parameters of a macro by name
+func macroParams(n *node) map[string]string {
+	params := make(map[string]string)
+	for _, c := range n.children {
+		if c.name == "ac:parameter" {
+			params[c.attrs["ac:name"]] = strings.TrimSpace(c.textContent())
+		}
+	}
+	return params
+}
+
+// renderInlines renders inline nodes; block elements among them are flattened
+func renderInlines(nodes []*node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(renderInline(n))
+	}
+	return b.String()
+}
+
+// renderInline renders one inline node
+func renderInline(n *node) string {
+	switch n.name {
+	case "":
+		return escapeText(whitespacePattern.ReplaceAllString(n.text, " "))
+	case "strong", "b":
+		return wrap(renderInlines(n.children), "**")
+	case "em", "i":
+		return wrap(renderInlines(n.children), "*")
+	case "del", "s", "strike":
+		return wrap(renderInlines(n.children), "~~")
+	case "code", "tt":
+		return codeSpanFor(n.textContent())
+	case "br":
+		return "\\\n"
+	case "a":
+		text := strings.TrimSpace(renderInlines(n.children))
+		href := n.attrs["href"]
+		if href == "" {
+			return text
+		}
+		if text == "" || text == markdownEscaper.Replace(href) {
+			return "<" + href + ">"
+		}
+		return "[" + text + "](" + destination(href) + ")"
+	case "img":
+		return "![" + markdownEscaper.Replace(n.attrs["alt"]) + "](" + destination(n.attrs["src"]) + ")"
+	case "ac:link":
+		return renderLink(n)
+	case "ac:image":
+		src := ""
+		if ri := n.child("ri:attachment"); ri != nil {
+			src = ri.attrs["ri:filename"]
+		} else if ri := n.child("ri:url"); ri != nil {
+			src = ri.attrs["ri:value"]
+		}
+		return "![" + markdownEscaper.Replace(n.attrs["ac:alt"]) + "](" + destination(src) + ")"
+	case "ac:structured-macro":
+		params := macroParams(n)
+		switch n.attrs["ac:name"] {
+		case "status":
+			return "[" + markdownEscaper.Replace(params["title"]) + "]"
+		case "jira":
+			return markdownEscaper.Replace(params["key"])
+		case "anchor":
+			return ""
+		}
+		return renderBlock(n)
+	case "ac:emoticon":
+		return n.attrs["ac:emoji-fallback"]
+	case "time":
+		return n.attrs["datetime"]
+	case "ac:parameter", "ac:placeholder":
+		return ""
+	default:
+		return renderInlines(n.children)
+	}
+}
+
+// renderLink renders a link to a page, attachment, user or URL. Page links keep the
+// page title as their destination, which ToStorage turns back into a page link.
+func renderLink(n *node) string {
+	text := ""
+	if body := n.child("ac:link-body"); body != nil {
+		text = strings.TrimSpace(renderInlines(body.children))
+	} else if body := n.child("ac:plain-text-link-body"); body != nil {
+		text = markdownEscaper.Replace(strings.TrimSpace(body.textContent()))
+	}
+
+	switch {
+	case n.child("ri:page") != nil:
+		title := n.child("ri:page").attrs["ri:content-title"]
+		if text == "" {
+			text = markdownEscaper.Replace(title)
+		}
+		return "[" + text + "](" + destination(title) + ")"
+	case n.child("ri:url") != nil:
+		href := n.child("ri:url").attrs["ri:value"]
+		if text == "" {
+			return "<" + href + ">"
+		}
+		return "[" + text + "](" + destination(href) + ")"
+	case n.child("ri:user") != nil:
+		if text != "" {
+			return text
+		}
+		user := n.child("ri:user")
+		id := user.attrs["ri:account-id"]
+		if id == "" {
+			id = user.attrs["ri:userkey"]
+		}
+		return "@" + id
+	case n.child("ri:attachment") != nil:
+		if text == "" {
+			text = markdownEscaper.Replace(n.child("ri:attachment").attrs["ri:filename"])
+		}
+		return text
+	}
+	if text == "" && n.attrs["ac:anchor"] != "" {
+		text = markdownEscaper.Replace(n.attrs["ac:anchor"])
+	}
+	return text
+}
+
+// destination formats a link destination, using the angle bracket form when it
+// contains spaces or parentheses
+func destination(dest string) string {
+	if strings.ContainsAny(dest, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(dest) + ">"
+	}
+	return dest
+}
+
+// wrap surrounds text with an emphasis delimiter, keeping surrounding spaces outside it
+func wrap(text, delimiter string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + delimiter + trimmed + delimiter + text[start+len(trimmed):]
+}
+
+// escapeText escapes the characters of text that Markdown would interpret. Underscores
+// inside words are left alone since they never start emphasis.
+func escapeText(text string) string {
+	text = markdownEscaper.Replace(text)
+	if !strings.Contains(text, "_") {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '_' && (i == 0 || i == len(text)-1 || !isAlnum(text[i-1]) || !isAlnum(text[i+1])) {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+// codeSpanFor returns a code span showing code, using more backticks than code contains
+func codeSpanFor(code string) string {
+	code = whitespacePattern.ReplaceAllString(code, " ")
+	if code == "" {
+		return ""
+	}
+	ticks := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		return ticks + " " + code + " " + ticks
+	}
+	return ticks + code + ticks
+}
+
+// fence returns a fenced code block showing code
+func fence(code, language string) string {
+	code = strings.Trim(code, "\n")
+	ticks := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	return ticks + language + "\n" + code + "\n" + ticks
+}
+
+// quoteLines prefixes each line of text with "> "
+func quoteLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentLines indents every line of text but the first by n spaces
+func indentLines(text string, n int) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = pad + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// longestRun returns the length of the longest run of c in s
+func longestRun(s string, c byte) int {
+	longest := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			n := runLength(s[i:], c)
+			longest = max(longest, n)
+			i += n - 1
+		}
+	}
+	return longest
+}
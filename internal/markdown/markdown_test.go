@@ -0,0 +1,104 @@
+package markdown
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// update rewrites the golden files from the current output: go test ./internal/markdown -update
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// goldenCases runs fn on every input file in dir with the given extension and compares
+// its output to the input's .golden file
+func goldenCases(t *testing.T, dir, ext string, fn func(t *testing.T, input string) string) {
+	t.Helper()
+	inputs, err := filepath.Glob(filepath.Join("testdata", dir, "*"+ext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatalf("no test cases in testdata/%s", dir)
+	}
+
+	for _, path := range inputs {
+		name := strings.TrimSuffix(filepath.Base(path), ext)
+		t.Run(name, func(t *testing.T) {
+			input, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := fn(t, string(input))
+
+			golden := strings.TrimSuffix(path, ext) + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("output differs from %s\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestToStorageGolden(t *testing.T) {
+	goldenCases(t, "to_storage", ".md", func(t *testing.T, md string) string {
+		storage := ToStorage(md)
+		// The result must parse as storage format
+		if _, err := FromStorage(storage); err != nil {
+			t.Fatalf("ToStorage produced storage format that does not parse: %v", err)
+		}
+		return storage
+	})
+}
+
+func TestFromStorageGolden(t *testing.T) {
+	goldenCases(t, "from_storage", ".xml", func(t *testing.T, storage string) string {
+		md, err := FromStorage(strings.TrimSpace(storage))
+		if err != nil {
+			t.Fatalf("FromStorage: %v", err)
+		}
+		return md + "\n"
+	})
+}
+
+// TestRoundTrip checks that Markdown read back from a page reads the same after it is
+// written again, so a read-edit-write cycle only changes what the agent edited. Storage
+// format cases are not round-tripped: macros without a Markdown equivalent are
+// flattened by design.
+func TestRoundTrip(t *testing.T) {
+	goldens, err := filepath.Glob(filepath.Join("testdata", "to_storage", "*.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range goldens {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".golden"), func(t *testing.T) {
+			storage, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			md, err := FromStorage(string(storage))
+			if err != nil {
+				t.Fatalf("FromStorage: %v", err)
+			}
+			again, err := FromStorage(ToStorage(md))
+			if err != nil {
+				t.Fatalf("FromStorage: %v", err)
+			}
+			if again != md {
+				t.Errorf("Markdown changed in a round trip\n--- before ---\n%s\n--- after ---\n%s", md, again)
+			}
+		})
+	}
+}
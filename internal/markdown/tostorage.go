@@ -0,0 +1,649 @@
+// Package markdown converts between Markdown and Confluence storage format.
+// It covers what agents write and read: headings, paragraphs, emphasis, lists and
+// task lists, tables, code blocks (as code macros), links, images, quotes and
+// GitHub-style alerts (as info, tip, note and warning macros). Storage format with
+// no Markdown equivalent, such as layouts and most macros, is flattened to its text.
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	fencePattern       = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})[ \t]*([^`\\s]*)")
+	headingPattern     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextPattern      = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	rulePattern        = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	quotePattern       = regexp.MustCompile(`^ {0,3}> ?`)
+	itemPattern        = regexp.MustCompile(`^( {0,3})([-*+]|\d{1,9}[.)])(?:[ \t]+|$)`)
+	taskPattern        = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+	alertPattern       = regexp.MustCompile(`^\[!(NOTE|TIP|IMPORTANT|WARNING|CAUTION)\][ \t]*$`)
+	delimiterRow       = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	autolinkPattern    = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^<>\s]*)>`)
+	breakPattern       = regexp.MustCompile(`^<br\s*/?>`)
+	bareURLPattern     = regexp.MustCompile(`^https?://[^\s<]+`)
+	urlPrefixPattern   = regexp.MustCompile(`^(?:[a-zA-Z][a-zA-Z0-9+.-]*://|mailto:|tel:|[/#?.])`)
+	textEscaper        = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attributeEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+	emphasisDelimiters = []string{"**", "__", "~~", "*", "_"}
+)
+
+// alertMacros maps GitHub alert types to the Confluence macros that render alike
+var alertMacros = map[string]string{
+	"NOTE":      "info",
+	"TIP":       "tip",
+	"IMPORTANT": "note",
+	"WARNING":   "warning",
+	"CAUTION":   "warning",
+}
+
+// emphasisTags maps emphasis delimiters to storage format elements
+var emphasisTags = map[string]string{
+	"**": "strong",
+	"__": "strong",
+	"~~": "del",
+	"*":  "em",
+	"_":  "em",
+}
+
+// ToStorage converts Markdown to Confluence storage format. Raw HTML is escaped
+// rather than passed through, so the result is always well-formed.
+func ToStorage(md string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		lines[i] = expandIndent(line)
+	}
+	return blocks(lines)
+}
+
+// blocks converts a sequence of block-level lines
+func blocks(lines []string) string {
+	var b strings.Builder
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+		case fencePattern.MatchString(line):
+			i = fencedCode(lines, i, &b)
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			fmt.Fprintf(&b, "<h%d>%s</h%d>", len(m[1]), inline(m[2]), len(m[1]))
+			i++
+		case rulePattern.MatchString(line):
+			b.WriteString("<hr />")
+			i++
+		case quotePattern.MatchString(line):
+			i = quote(lines, i, &b)
+		case isTableStart(lines, i):
+			i = table(lines, i, &b)
+		case itemPattern.MatchString(line):
+			i = list(lines, i, &b)
+		case indentOf(line) >= 4:
+			i = indentedCode(lines, i, &b)
+		default:
+			i = paragraph(lines, i, &b)
+		}
+	}
+	return b.String()
+}
+
+// interrupts reports whether a line starts a block that ends a paragraph
+func interrupts(lines []string, i int) bool {
+	line := lines[i]
+	return strings.TrimSpace(line) == "" || fencePattern.MatchString(line) || headingPattern.MatchString(line) ||
+		rulePattern.MatchString(line) || quotePattern.MatchString(line) || itemPattern.MatchString(line) ||
+		isTableStart(lines, i)
+}
+
+// paragraph converts a paragraph, or a setext heading, starting at line i
+func paragraph(lines []string, i int, b *strings.Builder) int {
+	var text []string
+	for ; i < len(lines); i++ {
+		if len(text) > 0 {
+			if m := setextPattern.FindStringSubmatch(lines[i]); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				fmt.Fprintf(b, "<h%d>%s</h%d>", level, inline(strings.Join(text, "\n")), level)
+				return i + 1
+			}
+			if interrupts(lines, i) {
+				break
+			}
+		}
+		text = append(text, strings.TrimLeft(lines[i], " "))
+	}
+
+	b.WriteString("<p>" + inline(hardBreaks(text)) + "</p>")
+	return i
+}
+
+// hardBreaks joins paragraph lines, turning lines that end in two spaces or a
+// backslash into line breaks
+func hardBreaks(text []string) string {
+	for j := 0; j < len(text)-1; j++ {
+		line := text[j]
+		switch {
+		case strings.HasSuffix(line, "  "):
+			text[j] = strings.TrimRight(line, " ") + "<br />"
+		case strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\"):
+			text[j] = strings.TrimSuffix(line, "\\") + "<br />"
+		default:
+			text[j] = strings.TrimRight(line, " \t")
+		}
+	}
+	return strings.TrimRight(strings.Join(text, "\n"), " \t")
+}
+
+// fencedCode converts a fenced code block into a code macro
+func fencedCode(lines []string, i int, b *strings.Builder) int {
+	m := fencePattern.FindStringSubmatch(lines[i])
+	indent, fence, language := len(m[1]), m[2], m[3]
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if indentOf(lines[i]) < 4 && strings.HasPrefix(trimmed, fence) &&
+			strings.Trim(trimmed, fence[:1]+" \t") == "" {
+			i++
+			break
+		}
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		code = append(code, line)
+	}
+
+	b.WriteString(codeMacro(strings.Join(code, "\n"), language))
+	return i
+}
+
+// indentedCode converts a code block indented by four spaces into a code macro
+func indentedCode(lines []string, i int, b *strings.Builder) int {
+	var code []string
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			code = append(code, "")
+			continue
+		}
+		if indentOf(lines[i]) < 4 {
+			break
+		}
+		code = append(code, lines[i][4:])
+	}
+	for len(code) > 0 && code[len(code)-1] == "" {
+		code = code[:len(code)-1]
+	}
+
+	b.WriteString(codeMacro(strings.Join(code, "\n"), ""))
+	return i
+}
+
+// codeMacro returns a code macro showing code
+func codeMacro(code, language string) string {
+	var b strings.Builder
+	b.WriteString(`<ac:structured-macro ac:name="code">`)
+	if language != "" {
+		b.WriteString(`<ac:parameter ac:name="language">` + textEscaper.Replace(strings.ToLower(language)) + `</ac:parameter>`)
+	}
+	// "]]>" cannot appear inside CDATA; split it across two sections
+	b.WriteString("<ac:plain-text-body><![CDATA[" + strings.ReplaceAll(code, "]]>", "]]]]><![CDATA[>") + "]]></ac:plain-text-body>")
+	b.WriteString("</ac:structured-macro>")
+	return b.String()
+}
+
+// quote converts a block quote, or a GitHub alert into the matching macro
+func quote(lines []string, i int, b *strings.Builder) int {
+	var inner []string
+	for ; i < len(lines) && quotePattern.MatchString(lines[i]); i++ {
+		inner = append(inner, lines[i][len(quotePattern.FindString(lines[i])):])
+	}
+
+	if m := alertPattern.FindStringSubmatch(strings.TrimSpace(inner[0])); m != nil {
+		fmt.Fprintf(b, `<ac:structured-macro ac:name="%s"><ac:rich-text-body>%s</ac:rich-text-body></ac:structured-macro>`,
+			alertMacros[m[1]], blocks(inner[1:]))
+		return i
+	}
+
+	b.WriteString("<blockquote>" + blocks(inner) + "</blockquote>")
+	return i
+}
+
+// isTableStart reports whether a GitHub table starts at line i
+func isTableStart(lines []string, i int) bool {
+	return i+1 < len(lines) && strings.Contains(lines[i], "|") && delimiterRow.MatchString(lines[i+1]) &&
+		strings.Contains(lines[i+1], "-")
+}
+
+// table converts a GitHub table; the first row becomes the header row
+func table(lines []string, i int, b *strings.Builder) int {
+	header := splitRow(lines[i])
+	b.WriteString("<table><tbody><tr>")
+	for _, cell := range header {
+		b.WriteString("<th>" + inline(cell) + "</th>")
+	}
+	b.WriteString("</tr>")
+
+	for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && !quotePattern.MatchString(lines[i]) &&
+		!fencePattern.MatchString(lines[i]) && !headingPattern.MatchString(lines[i]); i++ {
+		cells := splitRow(lines[i])
+		b.WriteString("<tr>")
+		for n := range header {
+			cell := ""
+			if n < len(cells) {
+				cell = cells[n]
+			}
+			b.WriteString("<td>" + inline(cell) + "</td>")
+		}
+		b.WriteString("</tr>")
+	}
+
+	b.WriteString("</tbody></table>")
+	return i
+}
+
+// splitRow splits a table row into its cells at unescaped pipes
+func splitRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, "\\|") {
+		row = row[:len(row)-1]
+	}
+
+	var cells []string
+	start := 0
+	for i := 0; i < len(row); i++ {
+		switch row[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, unescapePipes(row[start:i]))
+			start = i + 1
+		}
+	}
+	return append(cells, unescapePipes(row[start:]))
+}
+
+// unescapePipes trims a table cell and unescapes its pipes, including those in code spans
+func unescapePipes(cell string) string {
+	return strings.ReplaceAll(strings.TrimSpace(cell), `\|`, "|")
+}
+
+// list converts a bullet, ordered or task list starting at line i
+func list(lines []string, i int, b *strings.Builder) int {
+	ordered := isDigit(itemPattern.FindStringSubmatch(lines[i])[2][0])
+
+	var items [][]string
+	loose := false
+	for i < len(lines) {
+		m := itemPattern.FindStringSubmatch(lines[i])
+		if m == nil || isDigit(m[2][0]) != ordered || rulePattern.MatchString(lines[i]) {
+			break
+		}
+
+		contentIndent := len(m[0])
+		if !strings.HasSuffix(m[0], " ") && !strings.HasSuffix(m[0], "\t") {
+			contentIndent++
+		}
+		item := []string{lines[i][len(m[0]):]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			switch {
+			case strings.TrimSpace(line) == "":
+				item = append(item, "")
+				continue
+			case indentOf(line) >= contentIndent:
+				item = append(item, line[contentIndent:])
+				continue
+			case item[len(item)-1] != "" && !interrupts(lines, i):
+				// Lazy continuation of the item's paragraph
+				item = append(item, strings.TrimLeft(line, " "))
+				continue
+			}
+			break
+		}
+
+		n := len(item)
+		for n > 0 && item[n-1] == "" {
+			n--
+		}
+		for _, line := range item[:n] {
+			if line == "" {
+				loose = true
+			}
+		}
+		if next := itemPattern.FindStringSubmatch(lineAt(lines, i)); n < len(item) && next != nil && isDigit(next[2][0]) == ordered {
+			loose = true
+		}
+		items = append(items, item[:n])
+	}
+
+	if !ordered && isTaskList(items) {
+		b.WriteString("<ac:task-list>")
+		for _, item := range items {
+			m := taskPattern.FindStringSubmatch(item[0])
+			status := "incomplete"
+			if m[1] != " " {
+				status = "complete"
+			}
+			item[0] = item[0][len(m[0]):]
+			fmt.Fprintf(b, "<ac:task><ac:task-status>%s</ac:task-status><ac:task-body>%s</ac:task-body></ac:task>",
+				status, inline(hardBreaks(item)))
+		}
+		b.WriteString("</ac:task-list>")
+		return i
+	}
+
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag + ">")
+	for _, item := range items {
+		content := blocks(item)
+		if !loose && strings.HasPrefix(content, "<p>") {
+			end := strings.Index(content, "</p>")
+			content = content[len("<p>"):end] + content[end+len("</p>"):]
+		}
+		b.WriteString("<li>" + content + "</li>")
+	}
+	b.WriteString("</" + tag + ">")
+	return i
+}
+
+// isTaskList reports whether every item of a bullet list is a task
+func isTaskList(items [][]string) bool {
+	for _, item := range items {
+		if len(item) == 0 || !taskPattern.MatchString(item[0]) {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+// inline converts inline Markdown: code spans, links, images, emphasis and breaks
+func inline(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			b.WriteString(textEscaper.Replace(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if code, n := codeSpan(s[i:]); n > 0 {
+				b.WriteString("<code>" + textEscaper.Replace(code) + "</code>")
+				i += n
+				continue
+			}
+			n := runLength(s[i:], '`')
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+
+		case c == '!' && strings.HasPrefix(s[i+1:], "["):
+			if text, dest, n := link(s[i+1:]); n > 0 {
+				b.WriteString(image(text, dest))
+				i += 1 + n
+				continue
+			}
+
+		case c == '[':
+			if text, dest, n := link(s[i:]); n > 0 {
+				b.WriteString(anchor(text, dest))
+				i += n
+				continue
+			}
+
+		case c == '<':
+			if m := autolinkPattern.FindStringSubmatch(s[i:]); m != nil {
+				b.WriteString(`<a href="` + attributeEscaper.Replace(m[1]) + `">` + textEscaper.Replace(m[1]) + "</a>")
+				i += len(m[0])
+				continue
+			}
+			if m := breakPattern.FindString(s[i:]); m != "" {
+				b.WriteString("<br />")
+				i += len(m)
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if out, n := emphasis(s, i); n > 0 {
+				b.WriteString(out)
+				i += n
+				continue
+			}
+
+		case c == 'h' && (i == 0 || !isAlnum(s[i-1])):
+			if m := bareURLPattern.FindString(s[i:]); m != "" {
+				url := strings.TrimRight(m, ".,:;!?'\")*_")
+				b.WriteString(`<a href="` + attributeEscaper.Replace(url) + `">` + textEscaper.Replace(url) + "</a>")
+				i += len(url)
+				continue
+			}
+		}
+
+		b.WriteString(textEscaper.Replace(s[i : i+1]))
+		i++
+	}
+	return b.String()
+}
+
+// codeSpan returns the content of the code span at the start of s and its length,
+// or a length of 0 when the backticks are not closed
+func codeSpan(s string) (string, int) {
+	n := runLength(s, '`')
+	for i := n; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		m := runLength(s[i:], '`')
+		if m == n {
+			code := strings.ReplaceAll(s[n:i], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			return code, i + m
+		}
+		i += m
+	}
+	return "", 0
+}
+
+// link parses "[text](destination "title")" at the start of s, returning the text,
+// the destination and the length, or a length of 0 when s does not start with a link
+func link(s string) (string, string, int) {
+	depth := 0
+	end := -1
+	for i := 0; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			if _, n := codeSpan(s[i:]); n > 0 {
+				i += n - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+	}
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return "", "", 0
+	}
+
+	i := end + 2
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+
+	var dest string
+	if i < len(s) && s[i] == '<' {
+		close := strings.IndexAny(s[i:], ">\n")
+		if close < 0 || s[i+close] != '>' {
+			return "", "", 0
+		}
+		dest = s[i+1 : i+close]
+		i += close + 1
+	} else {
+		start, parens := i, 0
+		for ; i < len(s) && s[i] > ' '; i++ {
+			if s[i] == '(' {
+				parens++
+			} else if s[i] == ')' {
+				if parens == 0 {
+					break
+				}
+				parens--
+			}
+		}
+		dest = s[start:i]
+	}
+
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+		close := strings.IndexByte(s[i+1:], s[i])
+		if close < 0 {
+			return "", "", 0
+		}
+		i += close + 2
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", "", 0
+	}
+
+	return s[1:end], dest, i + 1
+}
+
+// anchor returns a link. Destinations that are not URLs name a page in the same space.
+func anchor(text, dest string) string {
+	if text == "" {
+		text = dest
+	}
+	if urlPrefixPattern.MatchString(dest) {
+		return `<a href="` + attributeEscaper.Replace(dest) + `">` + inline(text) + "</a>"
+	}
+	return `<ac:link><ri:page ri:content-title="` + attributeEscaper.Replace(dest) + `" /><ac:link-body>` +
+		inline(text) + "</ac:link-body></ac:link>"
+}
+
+// image returns an image. Sources that are not URLs name an attachment of the page.
+func image(alt, src string) string {
+	var b strings.Builder
+	b.WriteString("<ac:image")
+	if alt != "" {
+		b.WriteString(` ac:alt="` + attributeEscaper.Replace(alt) + `"`)
+	}
+	b.WriteString(">")
+	if urlPrefixPattern.MatchString(src) {
+		b.WriteString(`<ri:url ri:value="` + attributeEscaper.Replace(src) + `" />`)
+	} else {
+		b.WriteString(`<ri:attachment ri:filename="` + attributeEscaper.Replace(src) + `" />`)
+	}
+	b.WriteString("</ac:image>")
+	return b.String()
+}
+
+// emphasis converts the emphasis starting at s[i], returning its length, or a
+// length of 0 when the delimiter at s[i] is not closed
+func emphasis(s string, i int) (string, int) {
+	for _, d := range emphasisDelimiters {
+		if !strings.HasPrefix(s[i:], d) {
+			continue
+		}
+		open := i + len(d)
+		if open >= len(s) || isSpace(s[open]) || (d[0] == '_' && i > 0 && isAlnum(s[i-1])) {
+			continue
+		}
+
+		for j := open + 1; j+len(d) <= len(s); j++ {
+			if s[j] == '`' {
+				if _, n := codeSpan(s[j:]); n > 0 {
+					j += n - 1
+					continue
+				}
+			}
+			if !strings.HasPrefix(s[j:], d) || isSpace(s[j-1]) {
+				continue
+			}
+			if d[0] == '_' && j+len(d) < len(s) && isAlnum(s[j+len(d)]) {
+				continue
+			}
+			if len(d) == 1 && ((j+1 < len(s) && s[j+1] == d[0]) || s[j-1] == d[0]) {
+				// Part of a double delimiter that closes nested emphasis
+				continue
+			}
+			if len(d) == 2 && j+2 < len(s) && s[j+2] == d[0] {
+				// Close with the last two of a longer run, as in ***x***
+				j++
+			}
+			tag := emphasisTags[d]
+			return "<" + tag + ">" + inline(s[open:j]) + "</" + tag + ">", j + len(d) - i
+		}
+	}
+	return "", 0
+}
+
+// expandIndent replaces tabs in a line's indentation with four spaces
+func expandIndent(line string) string {
+	n := 0
+	for n < len(line) && (line[n] == ' ' || line[n] == '\t') {
+		n++
+	}
+	if !strings.Contains(line[:n], "\t") {
+		return line
+	}
+	return strings.ReplaceAll(line[:n], "\t", "    ") + line[n:]
+}
+
+// indentOf returns the number of leading spaces of a line
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// lineAt returns line i, or "" past the end
+func lineAt(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+// runLength returns how many times c repeats at the start of s
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' }
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
@@ -2,7 +2,7 @@ package models
 
 // ConfluenceRequest represents a request to the Confluence service
 type ConfluenceRequest struct {
-	Action      string         `json:"action"`       // get_page, search, create_page, update_page, list_spaces, copy_page
+	Action      string         `json:"action"`       // get_page, get_children, search, create_page, update_page, list_spaces, copy_page
 	WorkspaceID string         `json:"workspace_id"` // User's workspace label (e.g., "eso", "providentia")
 	UserID      string         `json:"user_id"`      // Clerk user ID
 	Params      map[string]any `json:"params"`       // Action-specific parameters
@@ -29,7 +29,8 @@ type ConfluencePage struct {
 
 // PageBody contains the page content
 type PageBody struct {
-	Storage StorageContent `json:"storage"`
+	Storage  StorageContent `json:"storage,omitzero"`
+	Markdown string         `json:"markdown,omitempty"` // Set instead of Storage when Markdown was requested
 }
 
 // StorageContent represents the storage format content
@@ -40,7 +41,9 @@ type StorageContent struct {
 
 // VersionInfo contains version information
 type VersionInfo struct {
-	Number int `json:"number"`
+	Number    int    `json:"number"`
+	Message   string `json:"message,omitempty"`   // Version comment
+	MinorEdit bool   `json:"minorEdit,omitempty"` // Skips watcher notifications
 }
 
 // SpaceRef references a Confluence space
@@ -64,6 +67,38 @@ type CreatePageRequest struct {
 	Ancestors []AncestorRef `json:"ancestors,omitempty"`
 }
 
+// UpdatePageRequest represents a request to replace a page's title and body, and
+// with Ancestors, move it under another parent. Version.Number must be one more
+// than the current version.
+type UpdatePageRequest struct {
+	ID        string        `json:"id"`
+	Type      string        `json:"type"`
+	Title     string        `json:"title"`
+	Body      BodyContent   `json:"body"`
+	Version   VersionInfo   `json:"version"`
+	Ancestors []AncestorRef `json:"ancestors,omitempty"`
+}
+
+// Page update modes
+const (
+	UpdateModeReplace = "replace" // The body replaces the whole page
+	UpdateModeAppend  = "append"  // The body is added after the existing content
+	UpdateModeSection = "section" // The body replaces the content under one heading
+)
+
+// Page body formats accepted and returned by the page tools
+const (
+	BodyFormatStorage  = "storage"  // Confluence storage format (XHTML)
+	BodyFormatMarkdown = "markdown" // Converted to and from storage format by the service
+)
+
+// VersionConflict is the ErrorInfo.Details of an update made against a stale page version
+type VersionConflict struct {
+	PageID          string `json:"page_id"`
+	ExpectedVersion int    `json:"expected_version"` // The version the caller based its edit on
+	CurrentVersion  int    `json:"current_version"`
+}
+
 // BodyContent wraps the storage content
 type BodyContent struct {
 	Storage StorageContent `json:"storage"`
@@ -83,11 +118,141 @@ type ConfluenceSpace struct {
 	Description string `json:"description,omitempty"`
 }
 
+// ConfluenceUser represents a Confluence user
+type ConfluenceUser struct {
+	Type        string `json:"type"` // "known", or "anonymous" when credentials were not accepted
+	AccountID   string `json:"accountId,omitempty"`
+	Username    string `json:"username,omitempty"` // Data Center username
+	UserKey     string `json:"userKey,omitempty"`  // Data Center user key
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email,omitempty"`
+}
+
 // SearchResults represents Confluence search results
 type SearchResults struct {
 	Results []ConfluencePage `json:"results"`
 	Size    int              `json:"size"`
 	Limit   int              `json:"limit"`
 	Start   int              `json:"start"`
+
+	NextCursor string `json:"next_cursor,omitempty"` // Continues the listing; empty after the last page
+}
+
+// SearchHit is one result of a structured search
+type SearchHit struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	Title        string    `json:"title"`
+	Excerpt      string    `json:"excerpt,omitempty"`
+	Space        *SpaceRef `json:"space,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	URL          string    `json:"url,omitempty"`
+}
+
+// SearchHits represents the results of a structured search
+type SearchHits struct {
+	Results    []SearchHit `json:"results"`
+	Size       int         `json:"size"`
+	TotalSize  int         `json:"total_size,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"` // Continues the listing; empty after the last page
+}
+
+// PageList is a page of pages, such as a parent page's children
+type PageList struct {
+	Results    []ConfluencePage `json:"results"`
+	NextCursor string           `json:"next_cursor,omitempty"` // Continues the listing; empty after the last page
 }
 
+// SpaceList is a page of spaces
+type SpaceList struct {
+	Results    []ConfluenceSpace `json:"results"`
+	NextCursor string            `json:"next_cursor,omitempty"` // Continues the listing; empty after the last page
+}
+
+// Title conflict strategies for page copies
+const (
+	CopyConflictFail      = "fail"      // Stop with an error (Confluence rejects duplicate titles in a space)
+	CopyConflictSkip      = "skip"      // Keep the existing page; its copied children go under it
+	CopyConflictSuffix    = "suffix"    // Create the copy with " (copy)", " (copy 2)", ... appended to the title
+	CopyConflictOverwrite = "overwrite" // Replace the existing page's body and move it under the copy's parent
+)
+
+// Outcomes of copying one page
+const (
+	CopyCreated     = "created"
+	CopySkipped     = "skipped"
+	CopyOverwritten = "overwritten"
+)
+
+// CopiedPage records what happened to one source page during a copy
+type CopiedPage struct {
+	SourceID      string `json:"source_id"`
+	DestinationID string `json:"destination_id"`
+	Title         string `json:"title"` // Title in the destination
+	Depth         int    `json:"depth"` // 0 for the root page
+	Outcome       string `json:"outcome"`
+
+	Attachments      int      `json:"attachments,omitempty"`       // Attachments transferred
+	AttachmentErrors []string `json:"attachment_errors,omitempty"` // Attachments that could not be transferred
+	LinksRewritten   int      `json:"links_rewritten,omitempty"`   // References pointed at copied pages
+}
+
+// Kinds of references found in copied pages
+const (
+	ReferencePageLink    = "page_link"
+	ReferenceJiraMacro   = "jira_macro"
+	ReferenceUserMention = "user_mention"
+)
+
+// What the copy did with a reference it could not resolve in the destination
+const (
+	ReferenceLinkedToSource = "linked_to_source" // Replaced by a plain link to the source site
+	ReferenceConvertedText  = "converted_to_text"
+	ReferenceKept           = "kept" // Left unchanged; it may not work in the destination
+)
+
+// How Jira issue macros and user mentions are handled in copies
+const (
+	CopyMacrosLink   = "link" // Jira macros become links to the source Jira
+	CopyMacrosKeep   = "keep"
+	CopyMentionsText = "text" // Mentions become the user's display name
+	CopyMentionsKeep = "keep"
+)
+
+// CopyReference is a reference in a copied page that does not resolve in the destination
+type CopyReference struct {
+	SourcePageID string `json:"source_page_id"`
+	Kind         string `json:"kind"`
+	Target       string `json:"target"` // Page title, issue key or JQL, or user ID
+	Action       string `json:"action"`
+}
+
+// CopyResult describes a page or page-tree copy
+type CopyResult struct {
+	Root      *ConfluencePage   `json:"root,omitempty"`      // The destination root page
+	PageMap   map[string]string `json:"page_map"`            // Source page ID to destination page ID
+	Pages     []CopiedPage      `json:"pages"`               // In copy order: parents before children, siblings in order
+	Truncated bool              `json:"truncated,omitempty"` // Pages below max_depth were left out
+
+	Unresolved []CopyReference `json:"unresolved,omitempty"` // References that could not be pointed at the destination
+}
+
+// ConfluenceAttachment represents a file attached to a page
+type ConfluenceAttachment struct {
+	ID         string               `json:"id"`
+	Title      string               `json:"title"` // File name; storage format references attachments by it
+	Version    VersionInfo          `json:"version"`
+	Extensions AttachmentExtensions `json:"extensions"`
+	Links      AttachmentLinks      `json:"_links"`
+}
+
+// AttachmentExtensions carries file metadata
+type AttachmentExtensions struct {
+	MediaType string `json:"mediaType"`
+	FileSize  int64  `json:"fileSize"`
+}
+
+// AttachmentLinks contains attachment links
+type AttachmentLinks struct {
+	Download string `json:"download"` // Relative to the Confluence base URL
+}
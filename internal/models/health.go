@@ -0,0 +1,86 @@
+package models
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Product health states reported by workspace_status
+const (
+	HealthOK          = "ok"           // Authenticated and the product answered
+	HealthDegraded    = "degraded"     // Authenticated, but a secondary check failed or the rate limit is nearly exhausted
+	HealthAuthFailed  = "auth_failed"  // The credentials were rejected
+	HealthUnavailable = "unavailable"  // The product is not installed at the URL, or is failing
+	HealthRateLimited = "rate_limited" // Atlassian is throttling the credentials
+	HealthUnreachable = "unreachable"  // The request did not reach Atlassian
+	HealthDisabled    = "disabled"     // The product is not enabled for the workspace
+)
+
+// ProductHealth is the result of a live probe against one product
+type ProductHealth struct {
+	Product     string           `json:"product"`
+	Status      string           `json:"status"`
+	BaseURL     string           `json:"base_url,omitempty"`
+	LatencyMS   int64            `json:"latency_ms,omitempty"` // Round trip of the authentication check
+	Version     string           `json:"version,omitempty"`    // Server version, when the product reports it
+	Account     *CredentialCheck `json:"account,omitempty"`
+	AuthType    string           `json:"auth_type,omitempty"`
+	Scopes      []string         `json:"scopes,omitempty"`      // Granted OAuth 2.0 scopes
+	Permissions map[string]bool  `json:"permissions,omitempty"` // Global permissions held by the account
+	RateLimit   *RateLimitStatus `json:"rate_limit,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// RateLimitStatus reports the rate-limit headroom advertised by Atlassian response headers
+type RateLimitStatus struct {
+	Limit      int  `json:"limit,omitempty"`
+	Remaining  int  `json:"remaining,omitempty"`
+	NearLimit  bool `json:"near_limit,omitempty"`
+	RetryAfter int  `json:"retry_after_seconds,omitempty"`
+}
+
+// RateLimitFromHeaders reads the X-RateLimit-* and Retry-After headers, returning nil
+// when the response carried none (Data Center does not send them by default)
+func RateLimitFromHeaders(header http.Header) *RateLimitStatus {
+	status := &RateLimitStatus{}
+	found := false
+
+	if v, err := strconv.Atoi(header.Get("X-RateLimit-Limit")); err == nil {
+		status.Limit = v
+		found = true
+	}
+	if v, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err == nil {
+		status.Remaining = v
+		found = true
+	}
+	if v := header.Get("X-RateLimit-NearLimit"); v != "" {
+		status.NearLimit = strings.EqualFold(v, "true")
+		found = true
+	}
+	if v, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		status.RetryAfter = v
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return status
+}
+
+// HealthFromStatusCode maps the status code of an authentication check to a health state
+func HealthFromStatusCode(code int) string {
+	switch {
+	case code >= 200 && code < 300:
+		return HealthOK
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return HealthAuthFailed
+	case code == http.StatusNotFound || code >= 500:
+		return HealthUnavailable
+	case code == http.StatusTooManyRequests:
+		return HealthRateLimited
+	default:
+		return HealthDegraded
+	}
+}
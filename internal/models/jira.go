@@ -46,9 +46,11 @@ type IssueStatus struct {
 	Description string `json:"description,omitempty"`
 }
 
-// User represents a Jira user
+// User represents a Jira user. Cloud identifies users by accountId; Data Center uses name and key.
 type User struct {
-	AccountID   string `json:"accountId"`
+	AccountID   string `json:"accountId,omitempty"`
+	Name        string `json:"name,omitempty"` // Data Center username
+	Key         string `json:"key,omitempty"`  // Data Center user key
 	DisplayName string `json:"displayName"`
 	Email       string `json:"emailAddress,omitempty"`
 }
@@ -69,10 +71,12 @@ type IssueType struct {
 
 // SearchResponse represents Jira search results
 type SearchResponse struct {
-	StartAt    int         `json:"startAt"`
+	StartAt    *int        `json:"startAt,omitempty"` // Offset of the first issue; nil on Cloud, which pages by token
 	MaxResults int         `json:"maxResults"`
-	Total      int         `json:"total"`
+	Total      int         `json:"total,omitempty"` // Approximate on Cloud, where only a first page asked to count reports it
 	Issues     []JiraIssue `json:"issues"`
+
+	NextCursor string `json:"next_cursor,omitempty"` // Continues the search; empty after the last page
 }
 
 // CreateIssueRequest represents a request to create an issue
@@ -85,9 +89,15 @@ type UpdateIssueRequest struct {
 	Fields map[string]interface{} `json:"fields"`
 }
 
+// Formats of descriptions and comments sent to Jira
+const (
+	TextFormatMarkdown = "markdown" // Converted to ADF on Cloud
+	TextFormatPlain    = "text"     // Kept verbatim; line breaks become hard breaks on Cloud
+)
+
 // Comment represents a Jira comment
 type Comment struct {
-	Body    string `json:"body"`
+	Body    any    `json:"body"` // An ADF document on Cloud, a string on Data Center
 	Created string `json:"created,omitempty"`
 	Author  *User  `json:"author,omitempty"`
 }
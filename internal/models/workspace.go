@@ -1,50 +1,316 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Atlassian products a workspace can expose
+const (
+	ProductJira       = "jira"
+	ProductConfluence = "confluence"
+)
+
+// AllProducts lists every supported product
+var AllProducts = []string{ProductJira, ProductConfluence}
+
+// Deployment types
+const (
+	DeploymentCloud      = "cloud"      // Atlassian Cloud: REST v3, Basic email:token auth
+	DeploymentDataCenter = "datacenter" // Self-hosted Data Center: REST v2, Personal Access Token bearer auth
+)
+
+// Authentication types
+const (
+	AuthTypeAPIToken = "api_token" // Email + API token (Cloud) or Personal Access Token (Data Center)
+	AuthTypeOAuth2   = "oauth2"    // Atlassian OAuth 2.0 (3LO) access/refresh tokens
+)
+
+// Workspace sources reported by ListWorkspaces
+const (
+	WorkspaceSourcePersonal     = "personal"     // Stored by the user
+	WorkspaceSourceOrganization = "organization" // Registered by an organization admin and granted to the user
+)
+
+// Organization member roles
+const (
+	OrgRoleAdmin  = "admin"  // May share workspaces and manage members
+	OrgRoleMember = "member" // May use workspaces granted to them
+)
+
+// OAuthAPIGateway is the base URL for OAuth 2.0 API calls, addressed by cloud ID
+const OAuthAPIGateway = "https://api.atlassian.com"
+
+// OAuthToken holds Atlassian OAuth 2.0 (3LO) tokens
+type OAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Scope        string    `json:"scope,omitempty"`
+}
 
 // AtlassianCredential represents stored credentials for an Atlassian workspace
 type AtlassianCredential struct {
-	UserID        string    `json:"user_id"`        // Clerk user ID
-	WorkspaceID   string    `json:"workspace_id"`   // User-defined label (e.g., "eso", "providentia")
-	WorkspaceName string    `json:"workspace_name"` // Display name
-	AtlassianURL string    `json:"atlassian_url"`  // e.g., "https://providentia.atlassian.net"
-	Email         string    `json:"email"`          // Atlassian account email
-	APIToken      string    `json:"api_token"`     // Encrypted Atlassian API token
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	UserID         string      `json:"user_id"`                   // Clerk user ID
+	WorkspaceID    string      `json:"workspace_id"`              // User-defined label (e.g., "eso", "providentia")
+	WorkspaceName  string      `json:"workspace_name"`            // Display name
+	AtlassianURL   string      `json:"atlassian_url"`             // e.g., "https://providentia.atlassian.net"
+	JiraURL        string      `json:"jira_url,omitempty"`        // Optional Jira base URL, defaults to AtlassianURL
+	ConfluenceURL  string      `json:"confluence_url,omitempty"`  // Optional Confluence base URL, defaults to AtlassianURL + "/wiki"
+	Products       []string    `json:"products,omitempty"`        // Enabled products; empty means all
+	Deployment     string      `json:"deployment,omitempty"`      // "cloud" (default) or "datacenter"
+	AuthType       string      `json:"auth_type,omitempty"`       // "api_token" (default) or "oauth2"
+	CloudID        string      `json:"cloud_id,omitempty"`        // Atlassian cloud ID, required for OAuth 2.0
+	Email          string      `json:"email"`                     // Atlassian account email (not needed for Data Center)
+	APIToken       string      `json:"api_token"`                 // Encrypted Atlassian API token
+	OAuth          *OAuthToken `json:"-"`                         // OAuth 2.0 tokens, never serialized
+	Source         string      `json:"source,omitempty"`          // "personal" or "organization"
+	OrganizationID string      `json:"organization_id,omitempty"` // Owning organization of a shared workspace
+	Overrides      string      `json:"overrides,omitempty"`       // Organization whose shared workspace this personal one overrides
+	Default        bool        `json:"default,omitempty"`         // The user's default workspace
+	Aliases        []string    `json:"aliases,omitempty"`         // The user's aliases for this workspace
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// ProductURL returns the base URL to use for a product
+func (c *AtlassianCredential) ProductURL(product string) string {
+	if c.AuthKind() == AuthTypeOAuth2 {
+		return oauthProductURL(c.CloudID, product)
+	}
+	return resolveProductURL(c.AtlassianURL, c.JiraURL, c.ConfluenceURL, c.Deployment, product)
+}
+
+// AuthKind returns the normalized authentication type
+func (c *AtlassianCredential) AuthKind() string {
+	return normalizeAuthType(c.AuthType)
+}
+
+// DeploymentType returns the normalized deployment type
+func (c *AtlassianCredential) DeploymentType() string {
+	return normalizeDeployment(c.Deployment)
+}
+
+// HasProduct reports whether a product is enabled for the workspace
+func (c *AtlassianCredential) HasProduct(product string) bool {
+	return productEnabled(c.Products, product)
+}
+
+// EnabledProducts returns the enabled products, expanding an empty list to all products
+func (c *AtlassianCredential) EnabledProducts() []string {
+	return enabledProducts(c.Products)
+}
+
+// WorkspacePreferences holds a user's default workspace and workspace aliases
+type WorkspacePreferences struct {
+	DefaultWorkspace string            `json:"default_workspace,omitempty"`
+	Aliases          map[string]string `json:"aliases,omitempty"` // Alias -> workspace ID
+}
+
+// Resolve maps an alias to its workspace ID and an empty ID to the default workspace
+func (p *WorkspacePreferences) Resolve(workspaceID string) string {
+	if p == nil {
+		return workspaceID
+	}
+	if workspaceID == "" {
+		return p.DefaultWorkspace
+	}
+	if target, ok := p.Aliases[workspaceID]; ok {
+		return target
+	}
+	return workspaceID
+}
+
+// AliasesFor returns the aliases pointing at a workspace
+func (p *WorkspacePreferences) AliasesFor(workspaceID string) []string {
+	if p == nil {
+		return nil
+	}
+	var aliases []string
+	for alias, target := range p.Aliases {
+		if target == workspaceID {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
 }
 
 // WorkspaceCredentials is used for API client creation
 type WorkspaceCredentials struct {
-	Site  string // e.g., "https://eso.atlassian.net/wiki" or "https://eso.atlassian.net"
-	Email string // e.g., "service@eso.com"
-	Token string // Decrypted API token
+	Site          string      // e.g., "https://eso.atlassian.net"
+	JiraURL       string      // Optional Jira base URL override
+	ConfluenceURL string      // Optional Confluence base URL override (e.g., "https://eso.atlassian.net/wiki")
+	Products      []string    // Enabled products; empty means all
+	Deployment    string      // "cloud" (default) or "datacenter"
+	AuthType      string      // "api_token" (default) or "oauth2"
+	CloudID       string      // Atlassian cloud ID for OAuth 2.0 API URLs
+	Email         string      // e.g., "service@eso.com"
+	Token         string      // Decrypted API token, or Personal Access Token for Data Center
+	OAuth         *OAuthToken // Decrypted OAuth 2.0 tokens when AuthType is "oauth2"
+}
+
+// ProductURL returns the base URL to use for a product
+func (c *WorkspaceCredentials) ProductURL(product string) string {
+	if c.AuthKind() == AuthTypeOAuth2 {
+		return oauthProductURL(c.CloudID, product)
+	}
+	return resolveProductURL(c.Site, c.JiraURL, c.ConfluenceURL, c.Deployment, product)
+}
+
+// BrowseURL returns the URL people use to open a product in a browser. It differs from
+// ProductURL for OAuth 2.0 workspaces, whose API calls go through the gateway; it is
+// empty when an OAuth 2.0 workspace has no site URL.
+func (c *WorkspaceCredentials) BrowseURL(product string) string {
+	return resolveProductURL(c.Site, c.JiraURL, c.ConfluenceURL, c.Deployment, product)
+}
+
+// AuthKind returns the normalized authentication type
+func (c *WorkspaceCredentials) AuthKind() string {
+	return normalizeAuthType(c.AuthType)
+}
+
+// DeploymentType returns the normalized deployment type
+func (c *WorkspaceCredentials) DeploymentType() string {
+	return normalizeDeployment(c.Deployment)
+}
+
+// HasProduct reports whether a product is enabled for the workspace
+func (c *WorkspaceCredentials) HasProduct(product string) bool {
+	return productEnabled(c.Products, product)
+}
+
+// resolveProductURL applies the per-product override, falling back to the site URL.
+// Confluence Cloud lives under /wiki, so it is appended when the site does not already include it;
+// Data Center installs have no fixed context path and use the site URL as is.
+func resolveProductURL(site, jiraURL, confluenceURL, deployment, product string) string {
+	switch product {
+	case ProductJira:
+		if jiraURL != "" {
+			return strings.TrimSuffix(jiraURL, "/")
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(site, "/"), "/wiki")
+	case ProductConfluence:
+		if confluenceURL != "" {
+			return strings.TrimSuffix(confluenceURL, "/")
+		}
+		site = strings.TrimSuffix(site, "/")
+		if site == "" || strings.HasSuffix(site, "/wiki") || normalizeDeployment(deployment) == DeploymentDataCenter {
+			return site
+		}
+		return site + "/wiki"
+	default:
+		return strings.TrimSuffix(site, "/")
+	}
+}
+
+// oauthProductURL returns the API gateway URL for a cloud ID. OAuth 2.0 tokens are only
+// accepted on api.atlassian.com, never on the site URL.
+func oauthProductURL(cloudID, product string) string {
+	switch product {
+	case ProductConfluence:
+		return OAuthAPIGateway + "/ex/confluence/" + cloudID + "/wiki"
+	default:
+		return OAuthAPIGateway + "/ex/jira/" + cloudID
+	}
+}
+
+// normalizeAuthType maps an empty value to the API token default
+func normalizeAuthType(authType string) string {
+	if strings.EqualFold(strings.TrimSpace(authType), AuthTypeOAuth2) {
+		return AuthTypeOAuth2
+	}
+	return AuthTypeAPIToken
+}
+
+// normalizeDeployment maps empty and alias values to a deployment constant
+func normalizeDeployment(deployment string) string {
+	switch strings.ToLower(strings.TrimSpace(deployment)) {
+	case DeploymentDataCenter, "data_center", "dc", "server":
+		return DeploymentDataCenter
+	default:
+		return DeploymentCloud
+	}
+}
+
+// productEnabled treats an empty product list as "all products"
+func productEnabled(products []string, product string) bool {
+	if len(products) == 0 {
+		return true
+	}
+	for _, p := range products {
+		if strings.EqualFold(strings.TrimSpace(p), product) {
+			return true
+		}
+	}
+	return false
+}
+
+// enabledProducts normalizes a product list
+func enabledProducts(products []string) []string {
+	var enabled []string
+	for _, p := range AllProducts {
+		if productEnabled(products, p) {
+			enabled = append(enabled, p)
+		}
+	}
+	return enabled
+}
+
+// CredentialCheck reports the identity a product authenticated a set of credentials as
+type CredentialCheck struct {
+	Product     string `json:"product"`
+	BaseURL     string `json:"base_url"`
+	AccountID   string `json:"account_id,omitempty"` // Cloud account ID, or username on Data Center
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email,omitempty"`
 }
 
 // ErrorInfo represents error information in responses
 type ErrorInfo struct {
-	Code    string `json:"code"`    // e.g., "AUTH_FAILED", "NOT_FOUND", "RATE_LIMITED"
-	Message string `json:"message"` // Human-readable message
+	Code    string `json:"code"`              // e.g., "AUTH_FAILED", "NOT_FOUND", "RATE_LIMITED"
+	Message string `json:"message"`           // Human-readable message
 	Details any    `json:"details,omitempty"` // Additional context
 }
 
 // Standard error codes
 const (
-	ErrCodeAuthFailed     = "AUTH_FAILED"
-	ErrCodeNotFound       = "NOT_FOUND"
-	ErrCodeRateLimited    = "RATE_LIMITED"
-	ErrCodeInvalidRequest = "INVALID_REQUEST"
-	ErrCodeAPIError       = "API_ERROR"
-	ErrCodeInternal       = "INTERNAL_ERROR"
+	ErrCodeAuthFailed      = "AUTH_FAILED"
+	ErrCodeForbidden       = "PERMISSION_DENIED" // Authenticated, but the account may not do this
+	ErrCodeNotFound        = "NOT_FOUND"
+	ErrCodeConflict        = "VERSION_CONFLICT" // The item changed since the caller read it
+	ErrCodeRateLimited     = "RATE_LIMITED"
+	ErrCodeInvalidRequest  = "INVALID_REQUEST"
+	ErrCodeAPIError        = "API_ERROR"
+	ErrCodeInternal        = "INTERNAL_ERROR"
+	ErrCodeProductDisabled = "PRODUCT_NOT_ENABLED"
+	ErrCodeTimeout         = "TIMEOUT"             // The backend service did not answer in time
+	ErrCodeUnavailable     = "SERVICE_UNAVAILABLE" // The backend service is unreachable or its circuit is open
 )
 
+// APIErrorDetails is the ErrorInfo.Details of a failed Atlassian request
+type APIErrorDetails struct {
+	Operation         string            `json:"operation,omitempty"` // What was attempted, e.g., "get issue PROJ-1"
+	Status            int               `json:"status,omitempty"`    // HTTP status returned by Atlassian
+	Messages          []string          `json:"messages,omitempty"`
+	FieldErrors       map[string]string `json:"field_errors,omitempty"` // Validation errors by field name
+	RetryAfterSeconds int               `json:"retry_after_seconds,omitempty"`
+}
+
 // ErrorResponse creates an error response
 func ErrorResponse(code, message string, requestID string) map[string]interface{} {
+	return ErrorResponseWithDetails(code, message, nil, requestID)
+}
+
+// ErrorResponseWithDetails creates an error response carrying additional context
+func ErrorResponseWithDetails(code, message string, details any, requestID string) map[string]interface{} {
 	return map[string]interface{}{
 		"success": false,
 		"error": &ErrorInfo{
 			Code:    code,
 			Message: message,
+			Details: details,
 		},
 		"request_id": requestID,
 	}
@@ -58,4 +324,3 @@ func SuccessResponse(data interface{}, requestID string) map[string]interface{}
 		"request_id": requestID,
 	}
 }
-
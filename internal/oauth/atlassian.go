@@ -0,0 +1,234 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// Atlassian OAuth 2.0 (3LO) endpoints
+const (
+	AuthorizeURL           = "https://auth.atlassian.com/authorize"
+	TokenURL               = "https://auth.atlassian.com/oauth/token"
+	AccessibleResourcesURL = "https://api.atlassian.com/oauth/token/accessible-resources"
+	MeURL                  = "https://api.atlassian.com/me"
+)
+
+// DefaultScopes covers every tool the MCP server exposes. offline_access is
+// required to receive a refresh token.
+var DefaultScopes = []string{
+	"read:jira-work",
+	"write:jira-work",
+	"read:jira-user",
+	"read:confluence-content.all",
+	"write:confluence-content",
+	"read:confluence-space.summary",
+	"search:confluence",
+	"readonly:content.attachment:confluence",
+	"write:confluence-file",
+	"read:me",
+	"offline_access",
+}
+
+// Config holds the OAuth 2.0 app registration from the Atlassian developer console
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // Must match the callback URL registered for the app
+	Scopes       []string
+	httpClient   *http.Client
+}
+
+// Resource is a site the user granted the app access to
+type Resource struct {
+	ID        string   `json:"id"` // Cloud ID
+	URL       string   `json:"url"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	AvatarURL string   `json:"avatarUrl,omitempty"`
+}
+
+// Profile identifies the user who granted consent
+type Profile struct {
+	AccountID string `json:"account_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+}
+
+// NewConfig creates an OAuth config
+func NewConfig(clientID, clientSecret, redirectURL string, scopes []string) *Config {
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+	return &Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		httpClient:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// ConfigFromEnv reads ATLASSIAN_OAUTH_CLIENT_ID, ATLASSIAN_OAUTH_CLIENT_SECRET,
+// ATLASSIAN_OAUTH_REDIRECT_URL and the optional space-separated ATLASSIAN_OAUTH_SCOPES.
+// It returns nil without an error when OAuth is not configured.
+func ConfigFromEnv() (*Config, error) {
+	clientID := os.Getenv("ATLASSIAN_OAUTH_CLIENT_ID")
+	if clientID == "" {
+		return nil, nil
+	}
+
+	clientSecret := os.Getenv("ATLASSIAN_OAUTH_CLIENT_SECRET")
+	if clientSecret == "" {
+		return nil, fmt.Errorf("ATLASSIAN_OAUTH_CLIENT_SECRET is required when ATLASSIAN_OAUTH_CLIENT_ID is set")
+	}
+
+	return NewConfig(
+		clientID,
+		clientSecret,
+		os.Getenv("ATLASSIAN_OAUTH_REDIRECT_URL"),
+		strings.Fields(os.Getenv("ATLASSIAN_OAUTH_SCOPES")),
+	), nil
+}
+
+// AuthCodeURL returns the consent page URL the user must visit
+func (c *Config) AuthCodeURL(state string) string {
+	params := url.Values{}
+	params.Set("audience", "api.atlassian.com")
+	params.Set("client_id", c.ClientID)
+	params.Set("scope", strings.Join(c.Scopes, " "))
+	params.Set("redirect_uri", c.RedirectURL)
+	params.Set("state", state)
+	params.Set("response_type", "code")
+	params.Set("prompt", "consent")
+	return AuthorizeURL + "?" + params.Encode()
+}
+
+// Exchange trades an authorization code for tokens
+func (c *Config) Exchange(code string) (*models.OAuthToken, error) {
+	return c.requestToken(map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     c.ClientID,
+		"client_secret": c.ClientSecret,
+		"code":          code,
+		"redirect_uri":  c.RedirectURL,
+	}, "")
+}
+
+// Refresh obtains a new access token. Atlassian rotates refresh tokens, so the
+// returned token must be persisted in place of the old one.
+func (c *Config) Refresh(refreshToken string) (*models.OAuthToken, error) {
+	return c.requestToken(map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     c.ClientID,
+		"client_secret": c.ClientSecret,
+		"refresh_token": refreshToken,
+	}, refreshToken)
+}
+
+// AccessibleResources lists the sites (and their cloud IDs) a token can access
+func (c *Config) AccessibleResources(accessToken string) ([]Resource, error) {
+	var resources []Resource
+	if err := c.getJSON(AccessibleResourcesURL, accessToken, &resources); err != nil {
+		return nil, fmt.Errorf("failed to list accessible resources: %w", err)
+	}
+	return resources, nil
+}
+
+// Me returns the profile of the user who owns a token
+func (c *Config) Me(accessToken string) (*Profile, error) {
+	var profile Profile
+	if err := c.getJSON(MeURL, accessToken, &profile); err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// requestToken calls the token endpoint. previousRefresh is kept when the
+// response does not include a new refresh token.
+func (c *Config) requestToken(payload map[string]string, previousRefresh string) (*models.OAuthToken, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", TokenURL, strings.NewReader(string(jsonPayload)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return nil, fmt.Errorf("token request failed: %s: %s", oauthErr.Error, oauthErr.Description)
+		}
+		return nil, fmt.Errorf("token request failed with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Scope        string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("token response did not include an access token")
+	}
+
+	token := &models.OAuthToken{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+		Scope:        result.Scope,
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = previousRefresh
+	}
+
+	return token, nil
+}
+
+// getJSON performs an authenticated GET against api.atlassian.com
+func (c *Config) getJSON(url, accessToken string, out any) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,88 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// refreshLeeway refreshes access tokens slightly before they expire, so a
+// request never starts with a token that lapses in flight
+const refreshLeeway = 60 * time.Second
+
+// TokenStore persists refreshed tokens
+type TokenStore interface {
+	UpdateOAuthToken(userID, workspaceID string, token *models.OAuthToken) error
+}
+
+// RefreshingTokenSource hands out access tokens for one workspace and refreshes
+// them before expiry, persisting the rotated tokens to the credential store
+type RefreshingTokenSource struct {
+	cfg         *Config
+	store       TokenStore
+	userID      string
+	workspaceID string
+	fingerprint string
+
+	mu    sync.Mutex
+	token models.OAuthToken
+}
+
+// NewRefreshingTokenSource creates a token source. cfg may be nil, in which case the
+// stored access token is used until it expires.
+func NewRefreshingTokenSource(cfg *Config, store TokenStore, userID, workspaceID string, token models.OAuthToken) *RefreshingTokenSource {
+	sum := sha256.Sum256([]byte(token.RefreshToken))
+	return &RefreshingTokenSource{
+		cfg:         cfg,
+		store:       store,
+		userID:      userID,
+		workspaceID: workspaceID,
+		fingerprint: hex.EncodeToString(sum[:]),
+		token:       token,
+	}
+}
+
+// Token returns a valid access token, refreshing it when it is about to expire
+func (s *RefreshingTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Until(s.token.ExpiresAt) > refreshLeeway {
+		return s.token.AccessToken, nil
+	}
+
+	if s.cfg == nil {
+		return "", errors.New("OAuth access token expired and no OAuth client is configured to refresh it")
+	}
+	if s.token.RefreshToken == "" {
+		return "", errors.New("OAuth access token expired and no refresh token is stored; reconnect the workspace")
+	}
+
+	refreshed, err := s.cfg.Refresh(s.token.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("failed to refresh OAuth token for workspace %s: %w", s.workspaceID, err)
+	}
+	if refreshed.Scope == "" {
+		refreshed.Scope = s.token.Scope
+	}
+	s.token = *refreshed
+
+	// The old refresh token is now invalid, so losing the new one would force re-consent
+	if s.store != nil {
+		if err := s.store.UpdateOAuthToken(s.userID, s.workspaceID, refreshed); err != nil {
+			return "", fmt.Errorf("failed to persist refreshed OAuth token for workspace %s: %w", s.workspaceID, err)
+		}
+	}
+
+	return s.token.AccessToken, nil
+}
+
+// Fingerprint identifies the stored token generation this source was created from
+func (s *RefreshingTokenSource) Fingerprint() string {
+	return s.fingerprint
+}
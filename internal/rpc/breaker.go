@@ -0,0 +1,128 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+// Circuit breaker states
+const (
+	StateClosed   CircuitState = iota // Calls pass through
+	StateOpen                         // Calls are rejected without reaching the service
+	StateHalfOpen                     // One trial call is let through to test the service
+)
+
+// String returns the state name used in metrics
+func (s CircuitState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops calls to a backend after consecutive failures and lets a
+// single trial call through once the open timeout has passed
+type CircuitBreaker struct {
+	threshold    int
+	openTimeout  time.Duration
+	onTransition func(from, to CircuitState)
+	now          func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool // A half-open trial call is in flight
+}
+
+// NewCircuitBreaker creates a closed circuit breaker. onTransition, if set, is called on
+// every state change while the breaker's lock is held, so it must not call back into it.
+func NewCircuitBreaker(threshold int, openTimeout time.Duration, onTransition func(from, to CircuitState)) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold:    threshold,
+		openTimeout:  openTimeout,
+		onTransition: onTransition,
+		now:          time.Now,
+	}
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen if not
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.transitionLocked(StateHalfOpen)
+		b.trial = true
+		return nil
+	case StateHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record reports the outcome of a call that Allow let through
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateHalfOpen:
+		b.trial = false
+		if success {
+			b.failures = 0
+			b.transitionLocked(StateClosed)
+		} else {
+			b.openedAt = b.now()
+			b.transitionLocked(StateOpen)
+		}
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openedAt = b.now()
+			b.transitionLocked(StateOpen)
+		}
+	}
+}
+
+// transitionLocked changes state; the caller must hold b.mu
+func (b *CircuitBreaker) transitionLocked(to CircuitState) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	if b.onTransition != nil {
+		b.onTransition(from, to)
+	}
+}
@@ -0,0 +1,166 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Call failures that never reached a service response
+var (
+	ErrTimeout     = errors.New("service call timed out")
+	ErrCircuitOpen = errors.New("service circuit is open")
+	ErrOverloaded  = errors.New("too many service calls are waiting for replies")
+)
+
+// metrics holds the counters of all service clients, published at /debug/vars
+var metrics = expvar.NewMap("rpc")
+
+// Client guards calls to one backend service with deadlines, retries for read
+// actions and a circuit breaker
+type Client struct {
+	service   string
+	cfg       Config
+	breaker   *CircuitBreaker
+	state     *expvar.String
+	pending   chan struct{} // One slot per publish still waiting for a reply; nil means no limit
+	abandoned *expvar.Int   // Timed-out publishes that have not returned yet
+}
+
+// NewClient creates a client for a named backend service (e.g., "jira")
+func NewClient(service string, cfg Config) *Client {
+	c := &Client{
+		service:   service,
+		cfg:       cfg,
+		state:     new(expvar.String),
+		abandoned: new(expvar.Int),
+	}
+	if cfg.MaxPending > 0 {
+		c.pending = make(chan struct{}, cfg.MaxPending)
+	}
+	c.state.Set(StateClosed.String())
+	metrics.Set(service+".state", c.state)
+	metrics.Set(service+".abandoned", c.abandoned)
+
+	c.breaker = NewCircuitBreaker(cfg.FailureThreshold, cfg.OpenTimeout, func(from, to CircuitState) {
+		c.state.Set(to.String())
+		c.count(fmt.Sprintf("transitions.%s_to_%s", from, to))
+	})
+
+	return c
+}
+
+// State returns the circuit breaker state
+func (c *Client) State() CircuitState {
+	return c.breaker.State()
+}
+
+// Call runs publish under the action's deadline. Read actions are retried with
+// backoff after timeouts and transport errors; write actions are attempted once,
+// since a timed-out write may still be applied. While the circuit is open, calls
+// fail immediately with ErrCircuitOpen.
+//
+// publish receives a context that is cancelled when the deadline passes. The transport
+// cannot interrupt a publish that is waiting for its reply, so a timed-out publish keeps
+// running, and its late reply is discarded. At most MaxPending publishes may be running
+// at once, abandoned ones included; beyond that calls fail with ErrOverloaded rather than
+// piling up more goroutines behind a service that is not answering.
+func (c *Client) Call(action string, publish func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	attempts := 1
+	if c.cfg.ReadActions[action] {
+		attempts += c.cfg.MaxRetries
+	}
+	timeout := c.cfg.timeout(action)
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			c.count("retries")
+			time.Sleep(c.backoff(attempt))
+		}
+
+		if err := c.breaker.Allow(); err != nil {
+			c.count("rejected")
+			return nil, fmt.Errorf("%w: %s service is unavailable after repeated failures, try again in %s",
+				ErrCircuitOpen, c.service, c.cfg.OpenTimeout)
+		}
+
+		c.count("calls")
+		response, err := c.callWithTimeout(publish, timeout)
+		if errors.Is(err, ErrOverloaded) {
+			c.count("overloaded")
+			return nil, fmt.Errorf("%w: %d calls to the %s service are still unanswered", err, c.cfg.MaxPending, c.service)
+		}
+		c.breaker.Record(err == nil)
+		if err == nil {
+			return response, nil
+		}
+
+		if errors.Is(err, ErrTimeout) {
+			c.count("timeouts")
+			lastErr = fmt.Errorf("%w: %s %s did not respond within %s", ErrTimeout, c.service, action, timeout)
+		} else {
+			c.count("failures")
+			lastErr = fmt.Errorf("%s %s failed: %w", c.service, action, err)
+		}
+	}
+
+	return nil, lastErr
+}
+
+// backoff returns the jittered delay before a retry
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.cfg.RetryBackoff << (attempt - 1)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// count increments a per-service counter
+func (c *Client) count(name string) {
+	metrics.Add(c.service+"."+name, 1)
+}
+
+// callWithTimeout runs publish and waits at most timeout for it. On timeout publish's
+// context is cancelled; the buffered channel lets it finish without a reader. The
+// publish holds a pending slot until it returns, not just until the call does.
+func (c *Client) callWithTimeout(publish func(ctx context.Context) ([]byte, error), timeout time.Duration) ([]byte, error) {
+	type result struct {
+		response []byte
+		err      error
+	}
+
+	if c.pending != nil {
+		select {
+		case c.pending <- struct{}{}:
+		default:
+			return nil, ErrOverloaded
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan result, 1)
+	go func() {
+		response, err := publish(ctx)
+		if c.pending != nil {
+			<-c.pending
+		}
+		done <- result{response, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-ctx.Done():
+		// Track publishes that outlive their call until they return
+		c.abandoned.Add(1)
+		go func() {
+			<-done
+			c.abandoned.Add(-1)
+		}()
+		return nil, ErrTimeout
+	}
+}
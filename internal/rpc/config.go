@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config controls deadlines, retries and circuit breaking for service calls
+type Config struct {
+	Timeout          time.Duration            // Deadline for actions without an entry in ActionTimeouts
+	ActionTimeouts   map[string]time.Duration // Per-action deadlines
+	ReadActions      map[string]bool          // Idempotent actions that may be retried
+	MaxRetries       int                      // Extra attempts for read actions after a timeout or transport error
+	RetryBackoff     time.Duration            // Base delay before the first retry, doubled for each further retry
+	FailureThreshold int                      // Consecutive failures that open the circuit
+	OpenTimeout      time.Duration            // How long an open circuit rejects calls before letting one through
+	MaxPending       int                      // Publishes that may wait for a reply at once, including timed-out ones; 0 means no limit
+}
+
+// DefaultConfig returns the default call policy
+func DefaultConfig() Config {
+	return Config{
+		Timeout: 30 * time.Second,
+		ActionTimeouts: map[string]time.Duration{
+			"create_issue": 60 * time.Second,
+			"update_issue": 60 * time.Second,
+			"create_page":  60 * time.Second,
+			"update_page":  60 * time.Second,
+			"copy_page":    5 * time.Minute,
+		},
+		ReadActions: map[string]bool{
+			"list_issues":          true,
+			"get_issue":            true,
+			"get_page":             true,
+			"get_children":         true,
+			"search":               true,
+			"list_spaces":          true,
+			"get_space":            true,
+			"health_check":         true,
+			"validate_credentials": true,
+		},
+		MaxRetries:       2,
+		RetryBackoff:     250 * time.Millisecond,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		MaxPending:       32,
+	}
+}
+
+// ConfigFromEnv reads RPC_TIMEOUT, RPC_ACTION_TIMEOUTS (e.g., "copy_page=10m,search=15s"),
+// RPC_MAX_RETRIES, RPC_CIRCUIT_THRESHOLD, RPC_CIRCUIT_OPEN_TIMEOUT and RPC_MAX_PENDING on
+// top of the defaults
+func ConfigFromEnv() (Config, error) {
+	cfg := DefaultConfig()
+
+	if v := os.Getenv("RPC_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid RPC_TIMEOUT: %q", v)
+		}
+		cfg.Timeout = d
+	}
+
+	if v := os.Getenv("RPC_ACTION_TIMEOUTS"); v != "" {
+		for _, entry := range strings.Split(v, ",") {
+			action, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok {
+				return cfg, fmt.Errorf("invalid RPC_ACTION_TIMEOUTS entry: %q", entry)
+			}
+			d, err := time.ParseDuration(strings.TrimSpace(value))
+			if err != nil || d <= 0 {
+				return cfg, fmt.Errorf("invalid RPC_ACTION_TIMEOUTS entry: %q", entry)
+			}
+			cfg.ActionTimeouts[strings.TrimSpace(action)] = d
+		}
+	}
+
+	if v := os.Getenv("RPC_MAX_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid RPC_MAX_RETRIES: %q", v)
+		}
+		cfg.MaxRetries = n
+	}
+
+	if v := os.Getenv("RPC_CIRCUIT_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return cfg, fmt.Errorf("invalid RPC_CIRCUIT_THRESHOLD: %q", v)
+		}
+		cfg.FailureThreshold = n
+	}
+
+	if v := os.Getenv("RPC_CIRCUIT_OPEN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid RPC_CIRCUIT_OPEN_TIMEOUT: %q", v)
+		}
+		cfg.OpenTimeout = d
+	}
+
+	if v := os.Getenv("RPC_MAX_PENDING"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid RPC_MAX_PENDING: %q", v)
+		}
+		cfg.MaxPending = n
+	}
+
+	return cfg, nil
+}
+
+// timeout returns the deadline for an action
+func (c Config) timeout(action string) time.Duration {
+	if d, ok := c.ActionTimeouts[action]; ok {
+		return d
+	}
+	return c.Timeout
+}
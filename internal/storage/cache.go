@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// DefaultCredentialCacheTTL is how long decrypted credentials stay cached
+const DefaultCredentialCacheTTL = 5 * time.Minute
+
+// CredentialVersioner is implemented by stores that can tell cheaply, without
+// decrypting anything, whether a user/workspace still resolves to the same stored
+// credentials. The version is opaque; any change to it means the credentials changed.
+type CredentialVersioner interface {
+	CredentialVersion(userID, workspaceID string) (string, error)
+}
+
+// CachingCredentialStore keeps decrypted credentials in memory for a bounded time,
+// so services that fetch credentials on every request do not pay the decryption
+// cost (PBKDF2 or a key provider round trip) each time.
+//
+// Each service process has its own cache, and only sees the invalidations it makes
+// itself. When the wrapped store is a CredentialVersioner, every hit is therefore
+// checked against the stored version, so a token rotated, deleted, refreshed or
+// revoked through another process is reloaded on the next request. Stores that
+// cannot report versions are only bounded by the TTL.
+//
+// Callers receive secrets as strings, which Go cannot wipe, so eviction only drops the
+// cache's references; secrets leave memory when the garbage collector reclaims them.
+type CachingCredentialStore struct {
+	inner    CredentialStoreInterface
+	versions CredentialVersioner // nil when the wrapped store cannot report versions
+	ttl      time.Duration
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry
+	prefs    map[string]*prefsEntry
+	gen      uint64 // Bumped by every invalidation, so loads that started earlier are not cached
+	now      func() time.Time
+}
+
+// prefsEntry holds one user's workspace preferences
+type prefsEntry struct {
+	prefs     *models.WorkspacePreferences
+	expiresAt time.Time
+}
+
+// cacheEntry holds one decrypted credential set
+type cacheEntry struct {
+	creds     models.WorkspaceCredentials
+	version   string
+	expiresAt time.Time
+}
+
+// NewCachingCredentialStore wraps a credential store with a decrypted-credential cache
+func NewCachingCredentialStore(inner CredentialStoreInterface, ttl time.Duration) *CachingCredentialStore {
+	versions, _ := inner.(CredentialVersioner)
+	return &CachingCredentialStore{
+		inner:    inner,
+		versions: versions,
+		ttl:      ttl,
+		entries:  make(map[string]*cacheEntry),
+		prefs:    make(map[string]*prefsEntry),
+		now:      time.Now,
+	}
+}
+
+// GetCredentials returns cached credentials when they are still current, loading them
+// from the wrapped store otherwise
+func (s *CachingCredentialStore) GetCredentials(userID, workspaceID string) (*models.WorkspaceCredentials, error) {
+	key := cacheKey(userID, workspaceID)
+
+	// Read the version before the credentials: a change in between leaves the entry
+	// with the older version, so it is reloaded on the next request rather than kept
+	version := ""
+	if s.versions != nil {
+		v, err := s.versions.CredentialVersion(userID, workspaceID)
+		if err != nil {
+			s.Invalidate(userID, workspaceID)
+			return nil, err
+		}
+		version = v
+	}
+
+	s.mu.Lock()
+	if entry, ok := s.entries[key]; ok {
+		if entry.version == version && s.now().Before(entry.expiresAt) {
+			creds := cloneCredentials(&entry.creds)
+			s.mu.Unlock()
+			return creds, nil
+		}
+		delete(s.entries, key)
+	}
+	gen := s.gen
+	s.mu.Unlock()
+
+	creds, err := s.inner.GetCredentials(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache keeps its own copy, so callers cannot modify cached credentials
+	entry := &cacheEntry{
+		creds:     *cloneCredentials(creds),
+		version:   version,
+		expiresAt: s.now().Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	s.purgeExpiredLocked()
+	if s.gen == gen {
+		s.entries[key] = entry
+	}
+	s.mu.Unlock()
+
+	return creds, nil
+}
+
+// SaveCredentials stores credentials and drops any cached copy
+func (s *CachingCredentialStore) SaveCredentials(cred *models.AtlassianCredential) error {
+	err := s.inner.SaveCredentials(cred)
+	s.Invalidate(cred.UserID, cred.WorkspaceID)
+	return err
+}
+
+// DeleteCredentials removes credentials and drops any cached copy
+func (s *CachingCredentialStore) DeleteCredentials(userID, workspaceID string) error {
+	err := s.inner.DeleteCredentials(userID, workspaceID)
+	s.Invalidate(userID, workspaceID)
+	return err
+}
+
+// UpdateOAuthToken stores refreshed OAuth tokens and drops any cached copy
+func (s *CachingCredentialStore) UpdateOAuthToken(userID, workspaceID string, token *models.OAuthToken) error {
+	err := s.inner.UpdateOAuthToken(userID, workspaceID, token)
+	s.Invalidate(userID, workspaceID)
+	return err
+}
+
+// ListWorkspaces is not cached; listings never contain secrets
+func (s *CachingCredentialStore) ListWorkspaces(userID string) ([]models.AtlassianCredential, error) {
+	return s.inner.ListWorkspaces(userID)
+}
+
+// Invalidate drops the cached credentials for a user/workspace
+func (s *CachingCredentialStore) Invalidate(userID, workspaceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	delete(s.entries, cacheKey(userID, workspaceID))
+}
+
+// Purge drops every cached credential
+func (s *CachingCredentialStore) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	s.entries = make(map[string]*cacheEntry)
+	s.prefs = make(map[string]*prefsEntry)
+}
+
+// Close purges the cache and closes the wrapped store
+func (s *CachingCredentialStore) Close() error {
+	s.Purge()
+	return s.inner.Close()
+}
+
+// purgeExpiredLocked evicts all expired entries; the caller must hold s.mu
+func (s *CachingCredentialStore) purgeExpiredLocked() {
+	now := s.now()
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// cloneCredentials copies credentials, including the products and OAuth token they point to
+func cloneCredentials(creds *models.WorkspaceCredentials) *models.WorkspaceCredentials {
+	clone := *creds
+	clone.Products = append([]string(nil), creds.Products...)
+	if creds.OAuth != nil {
+		oauth := *creds.OAuth
+		clone.OAuth = &oauth
+	}
+	return &clone
+}
+
+// cacheKey builds the map key for a user/workspace pair
+func cacheKey(userID, workspaceID string) string {
+	return userID + "\x00" + workspaceID
+}
+
+// organizations returns the wrapped organization store
+func (s *CachingCredentialStore) organizations() (OrganizationStore, error) {
+	return Organizations(s.inner)
+}
+
+// CreateOrganization creates an organization in the wrapped store
+func (s *CachingCredentialStore) CreateOrganization(orgID, name, adminUserID string) error {
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+	return orgs.CreateOrganization(orgID, name, adminUserID)
+}
+
+// SetMemberRole changes a membership; cached credentials are dropped since grants may now resolve differently
+func (s *CachingCredentialStore) SetMemberRole(orgID, userID, role string) error {
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+	defer s.Purge()
+	return orgs.SetMemberRole(orgID, userID, role)
+}
+
+// RemoveMember removes a membership and drops cached credentials
+func (s *CachingCredentialStore) RemoveMember(orgID, userID string) error {
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+	defer s.Purge()
+	return orgs.RemoveMember(orgID, userID)
+}
+
+// MemberRole is not cached
+func (s *CachingCredentialStore) MemberRole(orgID, userID string) (string, error) {
+	orgs, err := s.organizations()
+	if err != nil {
+		return "", err
+	}
+	return orgs.MemberRole(orgID, userID)
+}
+
+// SaveSharedCredentials stores an organization workspace and drops cached credentials
+func (s *CachingCredentialStore) SaveSharedCredentials(orgID string, cred *models.AtlassianCredential) error {
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+	defer s.Purge()
+	return orgs.SaveSharedCredentials(orgID, cred)
+}
+
+// DeleteSharedCredentials removes an organization workspace and drops cached credentials
+func (s *CachingCredentialStore) DeleteSharedCredentials(orgID, workspaceID string) error {
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+	defer s.Purge()
+	return orgs.DeleteSharedCredentials(orgID, workspaceID)
+}
+
+// GrantWorkspace shares an organization workspace and drops cached credentials
+func (s *CachingCredentialStore) GrantWorkspace(orgID, workspaceID, userID string) error {
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+	defer s.Purge()
+	return orgs.GrantWorkspace(orgID, workspaceID, userID)
+}
+
+// RevokeWorkspace withdraws a grant and drops cached credentials
+func (s *CachingCredentialStore) RevokeWorkspace(orgID, workspaceID, userID string) error {
+	orgs, err := s.organizations()
+	if err != nil {
+		return err
+	}
+	defer s.Purge()
+	return orgs.RevokeWorkspace(orgID, workspaceID, userID)
+}
+
+// GetPreferences returns cached workspace preferences, loading them on a miss
+func (s *CachingCredentialStore) GetPreferences(userID string) (*models.WorkspacePreferences, error) {
+	s.mu.Lock()
+	if entry, ok := s.prefs[userID]; ok && s.now().Before(entry.expiresAt) {
+		s.mu.Unlock()
+		return clonePreferences(entry.prefs), nil
+	}
+	gen := s.gen
+	s.mu.Unlock()
+
+	prefsStore, err := Preferences(s.inner)
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := prefsStore.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.gen == gen {
+		s.prefs[userID] = &prefsEntry{prefs: clonePreferences(prefs), expiresAt: s.now().Add(s.ttl)}
+	}
+	s.mu.Unlock()
+
+	return prefs, nil
+}
+
+// SetDefaultWorkspace updates the default workspace and drops the cached preferences
+func (s *CachingCredentialStore) SetDefaultWorkspace(userID, workspaceID string) error {
+	prefsStore, err := Preferences(s.inner)
+	if err != nil {
+		return err
+	}
+	defer s.invalidatePreferences(userID)
+	return prefsStore.SetDefaultWorkspace(userID, workspaceID)
+}
+
+// SetWorkspaceAlias updates an alias and drops the cached preferences
+func (s *CachingCredentialStore) SetWorkspaceAlias(userID, alias, workspaceID string) error {
+	prefsStore, err := Preferences(s.inner)
+	if err != nil {
+		return err
+	}
+	defer s.invalidatePreferences(userID)
+	return prefsStore.SetWorkspaceAlias(userID, alias, workspaceID)
+}
+
+// invalidatePreferences drops one user's cached preferences
+func (s *CachingCredentialStore) invalidatePreferences(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	delete(s.prefs, userID)
+}
+
+// clonePreferences copies preferences so cached values cannot be modified by callers
+func clonePreferences(prefs *models.WorkspacePreferences) *models.WorkspacePreferences {
+	clone := &models.WorkspacePreferences{
+		DefaultWorkspace: prefs.DefaultWorkspace,
+		Aliases:          make(map[string]string, len(prefs.Aliases)),
+	}
+	for alias, target := range prefs.Aliases {
+		clone.Aliases[alias] = target
+	}
+	return clone
+}
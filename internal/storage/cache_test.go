@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/crypto"
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// encryptedStore is an in-memory store that, like PostgresCredentialStore, decrypts
+// the token with the passphrase scheme on every GetCredentials and reports a version
+// that changes when the record is written
+type encryptedStore struct {
+	passphrase string
+	records    map[string]string // Encrypted tokens by cache key
+	version    int               // Bumped by writes, including ones made by other processes
+	reads      int
+	onRead     func() // Runs in the middle of GetCredentials, if set
+}
+
+func newEncryptedStore(tb testing.TB) *encryptedStore {
+	tb.Helper()
+	s := &encryptedStore{passphrase: "test-passphrase", records: map[string]string{}}
+	encrypted, err := crypto.Encrypt("api-token", s.passphrase)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	s.records[cacheKey("user", "ws")] = encrypted
+	return s
+}
+
+func (s *encryptedStore) CredentialVersion(userID, workspaceID string) (string, error) {
+	if _, ok := s.records[cacheKey(userID, workspaceID)]; !ok {
+		return "", ErrNotFound
+	}
+	return strconv.Itoa(s.version), nil
+}
+
+func (s *encryptedStore) GetCredentials(userID, workspaceID string) (*models.WorkspaceCredentials, error) {
+	s.reads++
+	if s.onRead != nil {
+		s.onRead()
+	}
+	encrypted, ok := s.records[cacheKey(userID, workspaceID)]
+	if !ok {
+		return nil, errors.New("workspace not found")
+	}
+	token, err := crypto.Decrypt(encrypted, s.passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &models.WorkspaceCredentials{
+		Site:     "https://example.atlassian.net",
+		Email:    "user@example.com",
+		Token:    token,
+		Products: []string{models.ProductJira},
+		OAuth:    &models.OAuthToken{AccessToken: "access", RefreshToken: "refresh"},
+	}, nil
+}
+
+func (s *encryptedStore) SaveCredentials(cred *models.AtlassianCredential) error { return nil }
+
+func (s *encryptedStore) DeleteCredentials(userID, workspaceID string) error { return nil }
+
+func (s *encryptedStore) UpdateOAuthToken(userID, workspaceID string, token *models.OAuthToken) error {
+	return nil
+}
+
+func (s *encryptedStore) ListWorkspaces(userID string) ([]models.AtlassianCredential, error) {
+	return nil, nil
+}
+
+func (s *encryptedStore) Close() error { return nil }
+
+func TestCachingCredentialStore(t *testing.T) {
+	inner := newEncryptedStore(t)
+	cache := NewCachingCredentialStore(inner, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	first, err := cache.GetCredentials("user", "ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Token != "api-token" {
+		t.Fatalf("Token = %q, want api-token", first.Token)
+	}
+
+	// Callers own the credentials they get; changing them leaves the cache intact
+	first.Token = "changed"
+	first.OAuth.AccessToken = "changed"
+	first.Products[0] = "changed"
+
+	second, err := cache.GetCredentials("user", "ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Token != "api-token" || second.OAuth.AccessToken != "access" || second.Products[0] != models.ProductJira {
+		t.Fatalf("cached credentials were modified through a returned copy: %+v", second)
+	}
+	if inner.reads != 1 {
+		t.Fatalf("inner reads = %d, want 1", inner.reads)
+	}
+
+	// Saving invalidates the entry
+	if err := cache.SaveCredentials(&models.AtlassianCredential{UserID: "user", WorkspaceID: "ws"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetCredentials("user", "ws"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.reads != 2 {
+		t.Fatalf("inner reads after save = %d, want 2", inner.reads)
+	}
+
+	// Entries expire after the TTL
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.GetCredentials("user", "ws"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.reads != 3 {
+		t.Fatalf("inner reads after expiry = %d, want 3", inner.reads)
+	}
+}
+
+func TestCachingCredentialStoreSeesOtherProcesses(t *testing.T) {
+	inner := newEncryptedStore(t)
+	cache := NewCachingCredentialStore(inner, time.Hour)
+
+	if _, err := cache.GetCredentials("user", "ws"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another service rotates the token: the stored version changes without this
+	// cache being told, and the next request reloads
+	inner.version++
+	if _, err := cache.GetCredentials("user", "ws"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.reads != 2 {
+		t.Fatalf("inner reads after a change elsewhere = %d, want 2", inner.reads)
+	}
+
+	// Another service revokes the grant or deletes the workspace: the cached copy is
+	// not served
+	delete(inner.records, cacheKey("user", "ws"))
+	if _, err := cache.GetCredentials("user", "ws"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetCredentials after removal = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCachingCredentialStoreInvalidateDuringLoad(t *testing.T) {
+	inner := newEncryptedStore(t)
+	cache := NewCachingCredentialStore(inner, time.Hour)
+
+	// A save lands while a load that read the old record is still decrypting it
+	inner.onRead = func() {
+		inner.onRead = nil
+		if err := cache.SaveCredentials(&models.AtlassianCredential{UserID: "user", WorkspaceID: "ws"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cache.GetCredentials("user", "ws"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The load that raced the save was not cached
+	if _, err := cache.GetCredentials("user", "ws"); err != nil {
+		t.Fatal(err)
+	}
+	if inner.reads != 2 {
+		t.Fatalf("inner reads = %d, want 2", inner.reads)
+	}
+}
+
+// BenchmarkGetCredentialsUncached is the per-request cost without the cache: one
+// PBKDF2 key derivation and decryption
+func BenchmarkGetCredentialsUncached(b *testing.B) {
+	store := newEncryptedStore(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.GetCredentials("user", "ws"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetCredentialsCached is the per-request cost with a warm cache; against
+// Postgres the version check adds one indexed query
+func BenchmarkGetCredentialsCached(b *testing.B) {
+	cache := NewCachingCredentialStore(newEncryptedStore(b), time.Hour)
+	if _, err := cache.GetCredentials("user", "ws"); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := cache.GetCredentials("user", "ws"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetCredentialsCachedParallel measures cache hits under concurrent requests
+func BenchmarkGetCredentialsCachedParallel(b *testing.B) {
+	cache := NewCachingCredentialStore(newEncryptedStore(b), time.Hour)
+	if _, err := cache.GetCredentials("user", "ws"); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := cache.GetCredentials("user", "ws"); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/crypto"
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
 )
 
@@ -15,16 +16,23 @@ type CredentialStoreInterface interface {
 	GetCredentials(userID, workspaceID string) (*models.WorkspaceCredentials, error)
 	SaveCredentials(cred *models.AtlassianCredential) error
 	DeleteCredentials(userID, workspaceID string) error
+	UpdateOAuthToken(userID, workspaceID string, token *models.OAuthToken) error
 	ListWorkspaces(userID string) ([]models.AtlassianCredential, error)
 	Close() error
 }
 
 // WorkspaceConfig represents the structure of workspaces.json
 type WorkspaceConfig struct {
-	Name     string `json:"name"`
-	BaseURL  string `json:"baseUrl"`
-	Email    string `json:"email"`
-	APIToken string `json:"apiToken"`
+	Name          string   `json:"name"`
+	BaseURL       string   `json:"baseUrl"`
+	JiraURL       string   `json:"jiraUrl,omitempty"`       // Optional, defaults to baseUrl
+	ConfluenceURL string   `json:"confluenceUrl,omitempty"` // Optional, defaults to baseUrl + "/wiki"
+	Products      []string `json:"products,omitempty"`      // Optional, e.g. ["jira"]; defaults to all products
+	Deployment    string   `json:"deployment,omitempty"`    // Optional, "cloud" (default) or "datacenter"
+	Aliases       []string `json:"aliases,omitempty"`       // Optional alternative names, e.g. ["eso"]
+	Default       bool     `json:"default,omitempty"`       // Optional, used when a tool call omits workspace_id
+	Email         string   `json:"email"`
+	APIToken      string   `json:"apiToken"`
 }
 
 // FileCredentialStore handles storage and retrieval of Atlassian credentials from a JSON file
@@ -87,9 +95,13 @@ func (s *FileCredentialStore) GetCredentials(userID, workspaceID string) (*model
 	}
 
 	return &models.WorkspaceCredentials{
-		Site:  ws.BaseURL,
-		Email: ws.Email,
-		Token: ws.APIToken,
+		Site:          ws.BaseURL,
+		JiraURL:       ws.JiraURL,
+		ConfluenceURL: ws.ConfluenceURL,
+		Products:      ws.Products,
+		Deployment:    ws.Deployment,
+		Email:         ws.Email,
+		Token:         ws.APIToken,
 	}, nil
 }
 
@@ -103,6 +115,11 @@ func (s *FileCredentialStore) DeleteCredentials(userID, workspaceID string) erro
 	return fmt.Errorf("file-based credential store is read-only")
 }
 
+// UpdateOAuthToken is not supported for file-based storage (read-only)
+func (s *FileCredentialStore) UpdateOAuthToken(userID, workspaceID string, token *models.OAuthToken) error {
+	return fmt.Errorf("file-based credential store is read-only")
+}
+
 // ListWorkspaces returns all workspaces from the file
 func (s *FileCredentialStore) ListWorkspaces(userID string) ([]models.AtlassianCredential, error) {
 	var credentials []models.AtlassianCredential
@@ -111,7 +128,11 @@ func (s *FileCredentialStore) ListWorkspaces(userID string) ([]models.AtlassianC
 			UserID:        userID, // Use provided userID or empty string
 			WorkspaceID:   name,
 			WorkspaceName: name,
-			AtlassianURL: ws.BaseURL,
+			AtlassianURL:  ws.BaseURL,
+			JiraURL:       ws.JiraURL,
+			ConfluenceURL: ws.ConfluenceURL,
+			Products:      ws.Products,
+			Deployment:    ws.Deployment,
 			Email:         ws.Email,
 			APIToken:      "", // Don't expose token in list
 			CreatedAt:     time.Now(),
@@ -128,7 +149,8 @@ func (s *FileCredentialStore) Close() error {
 
 // NewCredentialStoreFromEnv creates a credential store based on environment variables
 // If WORKSPACES_FILE is set, uses file-based storage
-// Otherwise, uses PostgreSQL storage (requires DATABASE_URL and API_KEY_ENCRYPTION_KEY)
+// Otherwise, uses PostgreSQL storage (requires DATABASE_URL and a key provider, see crypto.NewTokenCipherFromEnv)
+// wrapped in a decrypted-credential cache whose lifetime is set by CREDENTIAL_CACHE_TTL
 func NewCredentialStoreFromEnv() (CredentialStoreInterface, error) {
 	workspacesFile := os.Getenv("WORKSPACES_FILE")
 	if workspacesFile != "" {
@@ -142,11 +164,28 @@ func NewCredentialStoreFromEnv() (CredentialStoreInterface, error) {
 		return nil, fmt.Errorf("either WORKSPACES_FILE or DATABASE_URL must be set")
 	}
 
-	encryptionKey := os.Getenv("API_KEY_ENCRYPTION_KEY")
-	if encryptionKey == "" {
-		return nil, fmt.Errorf("API_KEY_ENCRYPTION_KEY is required when using database storage")
+	cipher, err := crypto.NewTokenCipherFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure token encryption: %w", err)
+	}
+
+	store, err := NewCredentialStore(databaseURL, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache decrypted credentials unless CREDENTIAL_CACHE_TTL is "0"
+	ttl := DefaultCredentialCacheTTL
+	if v := os.Getenv("CREDENTIAL_CACHE_TTL"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CREDENTIAL_CACHE_TTL: %w", err)
+		}
+	}
+	if ttl <= 0 {
+		return store, nil
 	}
 
-	return NewCredentialStore(databaseURL, encryptionKey)
+	return NewCachingCredentialStore(store, ttl), nil
 }
 
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// Organization workspaces are stored in atlassian_credentials under an owner key
+// derived from the organization ID, so they share the encryption and schema of
+// personal workspaces. Clerk user IDs never take this form.
+const orgOwnerPrefix = "org:"
+
+// grantAllMembers is the grantee that shares a workspace with every organization member
+const grantAllMembers = "*"
+
+// ErrOrganizationsUnsupported is returned by stores without organization support
+var ErrOrganizationsUnsupported = errors.New("credential store does not support organizations")
+
+// OrganizationStore manages organizations, their members and the workspaces they share.
+// An admin registers a workspace once for the organization and grants it to members;
+// a member's own workspace with the same ID overrides the shared one.
+type OrganizationStore interface {
+	CreateOrganization(orgID, name, adminUserID string) error
+	SetMemberRole(orgID, userID, role string) error
+	RemoveMember(orgID, userID string) error
+	MemberRole(orgID, userID string) (string, error)
+	SaveSharedCredentials(orgID string, cred *models.AtlassianCredential) error
+	DeleteSharedCredentials(orgID, workspaceID string) error
+	GrantWorkspace(orgID, workspaceID, userID string) error
+	RevokeWorkspace(orgID, workspaceID, userID string) error
+}
+
+// Organizations returns the organization store behind a credential store, if it has one
+func Organizations(store CredentialStoreInterface) (OrganizationStore, error) {
+	orgs, ok := store.(OrganizationStore)
+	if !ok {
+		return nil, ErrOrganizationsUnsupported
+	}
+	return orgs, nil
+}
+
+// orgOwner returns the owner key organization workspaces are stored under
+func orgOwner(orgID string) string {
+	return orgOwnerPrefix + orgID
+}
+
+// CreateOrganization creates an organization with its first admin
+func (s *CredentialStore) CreateOrganization(orgID, name, adminUserID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO organizations (org_id, name) VALUES ($1, $2)
+		ON CONFLICT (org_id) DO NOTHING
+	`, orgID, name)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("organization %s already exists", orgID)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO organization_members (org_id, user_id, role) VALUES ($1, $2, $3)
+	`, orgID, adminUserID, models.OrgRoleAdmin); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetMemberRole adds a member or changes their role
+func (s *CredentialStore) SetMemberRole(orgID, userID, role string) error {
+	if role != models.OrgRoleAdmin && role != models.OrgRoleMember {
+		return fmt.Errorf("invalid role %q", role)
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO organization_members (org_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (org_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`, orgID, userID, role)
+	return err
+}
+
+// RemoveMember removes a member and their workspace grants
+func (s *CredentialStore) RemoveMember(orgID, userID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM workspace_grants WHERE org_id = $1 AND user_id = $2`, orgID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM organization_members WHERE org_id = $1 AND user_id = $2`, orgID, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MemberRole returns a user's role in an organization, or ErrNotFound if they are not a member
+func (s *CredentialStore) MemberRole(orgID, userID string) (string, error) {
+	var role string
+	err := s.db.QueryRow(`
+		SELECT role FROM organization_members WHERE org_id = $1 AND user_id = $2
+	`, orgID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return role, err
+}
+
+// SaveSharedCredentials stores a workspace owned by an organization
+func (s *CredentialStore) SaveSharedCredentials(orgID string, cred *models.AtlassianCredential) error {
+	shared := *cred
+	shared.UserID = orgOwner(orgID)
+	return s.SaveCredentials(&shared)
+}
+
+// DeleteSharedCredentials removes an organization workspace and all of its grants
+func (s *CredentialStore) DeleteSharedCredentials(orgID, workspaceID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM workspace_grants WHERE org_id = $1 AND workspace_id = $2`, orgID, workspaceID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM atlassian_credentials WHERE user_id = $1 AND workspace_id = $2`,
+		orgOwner(orgID), workspaceID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GrantWorkspace shares an organization workspace with a member, or with every
+// member when userID is empty
+func (s *CredentialStore) GrantWorkspace(orgID, workspaceID, userID string) error {
+	if userID == "" {
+		userID = grantAllMembers
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO workspace_grants (org_id, workspace_id, user_id) VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, orgID, workspaceID, userID)
+	return err
+}
+
+// RevokeWorkspace withdraws a grant; an empty userID withdraws the all-members grant
+func (s *CredentialStore) RevokeWorkspace(orgID, workspaceID, userID string) error {
+	if userID == "" {
+		userID = grantAllMembers
+	}
+
+	_, err := s.db.Exec(`
+		DELETE FROM workspace_grants WHERE org_id = $1 AND workspace_id = $2 AND user_id = $3
+	`, orgID, workspaceID, userID)
+	return err
+}
+
+// sharedOwner finds the owner key of an organization workspace granted to a user.
+// When several organizations share the same workspace ID, the first by ID wins.
+func (s *CredentialStore) sharedOwner(userID, workspaceID string) (string, error) {
+	var orgID string
+	err := s.db.QueryRow(`
+		SELECT g.org_id
+		FROM workspace_grants g
+		JOIN organization_members m ON m.org_id = g.org_id AND m.user_id = $1
+		JOIN atlassian_credentials c ON c.user_id = $3 || g.org_id AND c.workspace_id = g.workspace_id
+		WHERE g.workspace_id = $2 AND (g.user_id = $1 OR g.user_id = $4)
+		ORDER BY g.org_id
+		LIMIT 1
+	`, userID, workspaceID, orgOwnerPrefix, grantAllMembers).Scan(&orgID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return orgOwner(orgID), nil
+}
@@ -2,6 +2,9 @@ package storage
 
 import (
 	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/providentiaww/trilix-atlassian-mcp/internal/crypto"
@@ -11,12 +14,12 @@ import (
 
 // CredentialStore handles storage and retrieval of Atlassian credentials
 type CredentialStore struct {
-	db *sql.DB
-	encryptionKey string
+	db     *sql.DB
+	cipher crypto.TokenCipher
 }
 
-// NewCredentialStore creates a new credential store
-func NewCredentialStore(connectionString, encryptionKey string) (*CredentialStore, error) {
+// NewCredentialStore creates a new credential store that protects tokens with the given cipher
+func NewCredentialStore(connectionString string, cipher crypto.TokenCipher) (*CredentialStore, error) {
 	db, err := sql.Open("postgres", connectionString)
 	if err != nil {
 		return nil, err
@@ -28,8 +31,8 @@ func NewCredentialStore(connectionString, encryptionKey string) (*CredentialStor
 	}
 
 	store := &CredentialStore{
-		db:            db,
-		encryptionKey: encryptionKey,
+		db:     db,
+		cipher: cipher,
 	}
 
 	// Initialize schema
@@ -56,23 +59,123 @@ func (s *CredentialStore) initSchema() error {
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_user_id ON atlassian_credentials(user_id);
+
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS jira_url VARCHAR(500) NOT NULL DEFAULT '';
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS confluence_url VARCHAR(500) NOT NULL DEFAULT '';
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS products VARCHAR(255) NOT NULL DEFAULT '';
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS deployment VARCHAR(20) NOT NULL DEFAULT 'cloud';
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS auth_type VARCHAR(20) NOT NULL DEFAULT 'api_token';
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS cloud_id VARCHAR(64) NOT NULL DEFAULT '';
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS oauth_access_token_encrypted TEXT NOT NULL DEFAULT '';
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS oauth_refresh_token_encrypted TEXT NOT NULL DEFAULT '';
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS oauth_expires_at TIMESTAMP;
+	ALTER TABLE atlassian_credentials ADD COLUMN IF NOT EXISTS oauth_scope TEXT NOT NULL DEFAULT '';
+
+	CREATE TABLE IF NOT EXISTS organizations (
+		org_id VARCHAR(255) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS organization_members (
+		org_id VARCHAR(255) NOT NULL REFERENCES organizations(org_id) ON DELETE CASCADE,
+		user_id VARCHAR(255) NOT NULL,
+		role VARCHAR(20) NOT NULL DEFAULT 'member',
+		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (org_id, user_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_org_member_user_id ON organization_members(user_id);
+
+	CREATE TABLE IF NOT EXISTS workspace_grants (
+		org_id VARCHAR(255) NOT NULL REFERENCES organizations(org_id) ON DELETE CASCADE,
+		workspace_id VARCHAR(255) NOT NULL,
+		user_id VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		PRIMARY KEY (org_id, workspace_id, user_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS workspace_preferences (
+		user_id VARCHAR(255) PRIMARY KEY,
+		default_workspace_id VARCHAR(255) NOT NULL DEFAULT '',
+		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS workspace_aliases (
+		user_id VARCHAR(255) NOT NULL,
+		alias VARCHAR(255) NOT NULL,
+		workspace_id VARCHAR(255) NOT NULL,
+		PRIMARY KEY (user_id, alias)
+	);
 	`
 
 	_, err := s.db.Exec(query)
 	return err
 }
 
-// GetCredentials retrieves and decrypts credentials for a user/workspace
+// GetCredentials retrieves and decrypts credentials for a user/workspace. The user's
+// own credentials take precedence over a workspace shared with them by an organization.
 func (s *CredentialStore) GetCredentials(userID, workspaceID string) (*models.WorkspaceCredentials, error) {
-	var encryptedToken, atlassianURL, email string
+	creds, err := s.getOwnedCredentials(userID, workspaceID)
+	if err != ErrNotFound {
+		return creds, err
+	}
+
+	owner, err := s.sharedOwner(userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.getOwnedCredentials(owner, workspaceID)
+}
+
+// CredentialVersion identifies the record GetCredentials resolves to and when it last
+// changed, without decrypting anything. It changes when the credentials are saved,
+// refreshed or deleted, and when a revoked grant stops resolving to a shared workspace.
+func (s *CredentialStore) CredentialVersion(userID, workspaceID string) (string, error) {
+	version, err := s.ownedVersion(userID, workspaceID)
+	if err != ErrNotFound {
+		return version, err
+	}
+
+	owner, err := s.sharedOwner(userID, workspaceID)
+	if err != nil {
+		return "", err
+	}
+	return s.ownedVersion(owner, workspaceID)
+}
+
+// ownedVersion returns the version of the credentials stored under an owner key
+func (s *CredentialStore) ownedVersion(ownerID, workspaceID string) (string, error) {
+	var updatedAt time.Time
+	err := s.db.QueryRow(`
+		SELECT updated_at FROM atlassian_credentials WHERE user_id = $1 AND workspace_id = $2
+	`, ownerID, workspaceID).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return ownerID + "@" + strconv.FormatInt(updatedAt.UnixNano(), 10), nil
+}
+
+// getOwnedCredentials retrieves and decrypts the credentials stored under an owner key
+func (s *CredentialStore) getOwnedCredentials(ownerID, workspaceID string) (*models.WorkspaceCredentials, error) {
+	var encryptedToken, atlassianURL, jiraURL, confluenceURL, products, deployment, email string
+	var authType, cloudID, encryptedAccess, encryptedRefresh, scope string
+	var expiresAt sql.NullTime
 
 	query := `
-		SELECT atlassian_url, email, api_token_encrypted
+		SELECT atlassian_url, jira_url, confluence_url, products, deployment, email, api_token_encrypted,
+			auth_type, cloud_id, oauth_access_token_encrypted, oauth_refresh_token_encrypted,
+			oauth_expires_at, oauth_scope
 		FROM atlassian_credentials
 		WHERE user_id = $1 AND workspace_id = $2
 	`
 
-	err := s.db.QueryRow(query, userID, workspaceID).Scan(&atlassianURL, &email, &encryptedToken)
+	err := s.db.QueryRow(query, ownerID, workspaceID).Scan(
+		&atlassianURL, &jiraURL, &confluenceURL, &products, &deployment, &email, &encryptedToken,
+		&authType, &cloudID, &encryptedAccess, &encryptedRefresh, &expiresAt, &scope)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrNotFound
@@ -81,36 +184,90 @@ func (s *CredentialStore) GetCredentials(userID, workspaceID string) (*models.Wo
 	}
 
 	// Decrypt token
-	token, err := crypto.Decrypt(encryptedToken, s.encryptionKey)
+	token, err := s.cipher.Decrypt(encryptedToken)
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.WorkspaceCredentials{
-		Site:  atlassianURL,
-		Email: email,
-		Token: token,
-	}, nil
+	creds := &models.WorkspaceCredentials{
+		Site:          atlassianURL,
+		JiraURL:       jiraURL,
+		ConfluenceURL: confluenceURL,
+		Products:      splitProducts(products),
+		Deployment:    deployment,
+		AuthType:      authType,
+		CloudID:       cloudID,
+		Email:         email,
+		Token:         token,
+	}
+
+	// Decrypt OAuth tokens
+	if encryptedAccess != "" {
+		accessToken, err := s.cipher.Decrypt(encryptedAccess)
+		if err != nil {
+			return nil, err
+		}
+		refreshToken := ""
+		if encryptedRefresh != "" {
+			refreshToken, err = s.cipher.Decrypt(encryptedRefresh)
+			if err != nil {
+				return nil, err
+			}
+		}
+		creds.OAuth = &models.OAuthToken{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			ExpiresAt:    expiresAt.Time,
+			Scope:        scope,
+		}
+	}
+
+	return creds, nil
 }
 
 // SaveCredentials encrypts and stores credentials
 func (s *CredentialStore) SaveCredentials(cred *models.AtlassianCredential) error {
 	// Encrypt token
-	encryptedToken, err := crypto.Encrypt(cred.APIToken, s.encryptionKey)
+	encryptedToken, err := s.cipher.Encrypt(cred.APIToken)
 	if err != nil {
 		return err
 	}
 
+	// Encrypt OAuth tokens
+	var encryptedAccess, encryptedRefresh, scope string
+	var expiresAt sql.NullTime
+	if cred.OAuth != nil {
+		encryptedAccess, encryptedRefresh, err = s.encryptOAuthToken(cred.OAuth)
+		if err != nil {
+			return err
+		}
+		expiresAt = sql.NullTime{Time: cred.OAuth.ExpiresAt, Valid: true}
+		scope = cred.OAuth.Scope
+	}
+
 	query := `
 		INSERT INTO atlassian_credentials 
-			(user_id, workspace_id, workspace_name, atlassian_url, email, api_token_encrypted, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			(user_id, workspace_id, workspace_name, atlassian_url, jira_url, confluence_url, products,
+			 deployment, email, api_token_encrypted, auth_type, cloud_id,
+			 oauth_access_token_encrypted, oauth_refresh_token_encrypted, oauth_expires_at, oauth_scope,
+			 created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		ON CONFLICT (user_id, workspace_id)
 		DO UPDATE SET
 			workspace_name = EXCLUDED.workspace_name,
 			atlassian_url = EXCLUDED.atlassian_url,
+			jira_url = EXCLUDED.jira_url,
+			confluence_url = EXCLUDED.confluence_url,
+			products = EXCLUDED.products,
+			deployment = EXCLUDED.deployment,
 			email = EXCLUDED.email,
 			api_token_encrypted = EXCLUDED.api_token_encrypted,
+			auth_type = EXCLUDED.auth_type,
+			cloud_id = EXCLUDED.cloud_id,
+			oauth_access_token_encrypted = EXCLUDED.oauth_access_token_encrypted,
+			oauth_refresh_token_encrypted = EXCLUDED.oauth_refresh_token_encrypted,
+			oauth_expires_at = EXCLUDED.oauth_expires_at,
+			oauth_scope = EXCLUDED.oauth_scope,
 			updated_at = EXCLUDED.updated_at
 	`
 
@@ -125,8 +282,18 @@ func (s *CredentialStore) SaveCredentials(cred *models.AtlassianCredential) erro
 		cred.WorkspaceID,
 		cred.WorkspaceName,
 		cred.AtlassianURL,
+		cred.JiraURL,
+		cred.ConfluenceURL,
+		strings.Join(cred.Products, ","),
+		cred.DeploymentType(),
 		cred.Email,
 		encryptedToken,
+		cred.AuthKind(),
+		cred.CloudID,
+		encryptedAccess,
+		encryptedRefresh,
+		expiresAt,
+		scope,
 		cred.CreatedAt,
 		cred.UpdatedAt,
 	)
@@ -134,6 +301,65 @@ func (s *CredentialStore) SaveCredentials(cred *models.AtlassianCredential) erro
 	return err
 }
 
+// UpdateOAuthToken replaces the stored OAuth tokens after a refresh, on the user's own
+// workspace or, failing that, on the organization workspace shared with them
+func (s *CredentialStore) UpdateOAuthToken(userID, workspaceID string, token *models.OAuthToken) error {
+	err := s.updateOwnedOAuthToken(userID, workspaceID, token)
+	if err != ErrNotFound {
+		return err
+	}
+
+	owner, err := s.sharedOwner(userID, workspaceID)
+	if err != nil {
+		return err
+	}
+	return s.updateOwnedOAuthToken(owner, workspaceID, token)
+}
+
+// updateOwnedOAuthToken replaces the OAuth tokens stored under an owner key
+func (s *CredentialStore) updateOwnedOAuthToken(ownerID, workspaceID string, token *models.OAuthToken) error {
+	encryptedAccess, encryptedRefresh, err := s.encryptOAuthToken(token)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE atlassian_credentials
+		SET oauth_access_token_encrypted = $3,
+			oauth_refresh_token_encrypted = $4,
+			oauth_expires_at = $5,
+			oauth_scope = $6,
+			updated_at = $7
+		WHERE user_id = $1 AND workspace_id = $2
+	`
+
+	result, err := s.db.Exec(query, ownerID, workspaceID,
+		encryptedAccess, encryptedRefresh, token.ExpiresAt, token.Scope, time.Now())
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// encryptOAuthToken encrypts the access and refresh tokens
+func (s *CredentialStore) encryptOAuthToken(token *models.OAuthToken) (string, string, error) {
+	encryptedAccess, err := s.cipher.Encrypt(token.AccessToken)
+	if err != nil {
+		return "", "", err
+	}
+	encryptedRefresh := ""
+	if token.RefreshToken != "" {
+		encryptedRefresh, err = s.cipher.Encrypt(token.RefreshToken)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return encryptedAccess, encryptedRefresh, nil
+}
+
 // DeleteCredentials removes credentials for a user/workspace
 func (s *CredentialStore) DeleteCredentials(userID, workspaceID string) error {
 	query := `
@@ -145,16 +371,64 @@ func (s *CredentialStore) DeleteCredentials(userID, workspaceID string) error {
 	return err
 }
 
-// ListWorkspaces returns all workspaces for a user
+// ListWorkspaces returns the user's own workspaces merged with the workspaces shared
+// with them by their organizations. A personal workspace with the same ID overrides the
+// shared one and records which organization it overrides.
 func (s *CredentialStore) ListWorkspaces(userID string) ([]models.AtlassianCredential, error) {
-	query := `
-		SELECT user_id, workspace_id, workspace_name, atlassian_url, email, created_at, updated_at
+	personal, err := s.queryWorkspaces(`
+		SELECT user_id, workspace_id, workspace_name, atlassian_url, jira_url, confluence_url, products,
+			deployment, auth_type, cloud_id, email, created_at, updated_at
 		FROM atlassian_credentials
 		WHERE user_id = $1
 		ORDER BY workspace_name
-	`
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := s.queryWorkspaces(`
+		SELECT DISTINCT ON (c.workspace_id) c.user_id, c.workspace_id, c.workspace_name, c.atlassian_url,
+			c.jira_url, c.confluence_url, c.products, c.deployment, c.auth_type, c.cloud_id, c.email,
+			c.created_at, c.updated_at
+		FROM workspace_grants g
+		JOIN organization_members m ON m.org_id = g.org_id AND m.user_id = $1
+		JOIN atlassian_credentials c ON c.user_id = $2 || g.org_id AND c.workspace_id = g.workspace_id
+		WHERE g.user_id = $1 OR g.user_id = $3
+		ORDER BY c.workspace_id, g.org_id
+	`, userID, orgOwnerPrefix, grantAllMembers)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := s.db.Query(query, userID)
+	byID := make(map[string]*models.AtlassianCredential, len(personal))
+	for i := range personal {
+		personal[i].Source = models.WorkspaceSourcePersonal
+		byID[personal[i].WorkspaceID] = &personal[i]
+	}
+
+	credentials := personal
+	for _, cred := range shared {
+		orgID := strings.TrimPrefix(cred.UserID, orgOwnerPrefix)
+		if own, ok := byID[cred.WorkspaceID]; ok {
+			own.Overrides = orgID
+			continue
+		}
+		cred.UserID = userID
+		cred.Source = models.WorkspaceSourceOrganization
+		cred.OrganizationID = orgID
+		credentials = append(credentials, cred)
+	}
+
+	sort.SliceStable(credentials, func(i, j int) bool {
+		return credentials[i].WorkspaceName < credentials[j].WorkspaceName
+	})
+
+	return credentials, nil
+}
+
+// queryWorkspaces runs a workspace listing query and scans its rows
+func (s *CredentialStore) queryWorkspaces(query string, args ...any) ([]models.AtlassianCredential, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -163,11 +437,18 @@ func (s *CredentialStore) ListWorkspaces(userID string) ([]models.AtlassianCrede
 	var credentials []models.AtlassianCredential
 	for rows.Next() {
 		var cred models.AtlassianCredential
+		var products string
 		err := rows.Scan(
 			&cred.UserID,
 			&cred.WorkspaceID,
 			&cred.WorkspaceName,
 			&cred.AtlassianURL,
+			&cred.JiraURL,
+			&cred.ConfluenceURL,
+			&products,
+			&cred.Deployment,
+			&cred.AuthType,
+			&cred.CloudID,
 			&cred.Email,
 			&cred.CreatedAt,
 			&cred.UpdatedAt,
@@ -175,6 +456,7 @@ func (s *CredentialStore) ListWorkspaces(userID string) ([]models.AtlassianCrede
 		if err != nil {
 			return nil, err
 		}
+		cred.Products = splitProducts(products)
 		credentials = append(credentials, cred)
 	}
 
@@ -186,6 +468,14 @@ func (s *CredentialStore) Close() error {
 	return s.db.Close()
 }
 
+// splitProducts parses the comma-separated products column; empty means all products
+func splitProducts(products string) []string {
+	if products == "" {
+		return nil
+	}
+	return strings.Split(products, ",")
+}
+
 var ErrNotFound = &NotFoundError{}
 
 type NotFoundError struct{}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/providentiaww/trilix-atlassian-mcp/internal/models"
+)
+
+// ErrPreferencesUnsupported is returned by stores without workspace preferences
+var ErrPreferencesUnsupported = errors.New("credential store does not support workspace preferences")
+
+// PreferenceStore manages each user's default workspace and workspace aliases
+type PreferenceStore interface {
+	GetPreferences(userID string) (*models.WorkspacePreferences, error)
+	SetDefaultWorkspace(userID, workspaceID string) error      // An empty workspaceID clears the default
+	SetWorkspaceAlias(userID, alias, workspaceID string) error // An empty workspaceID removes the alias
+}
+
+// Preferences returns the preference store behind a credential store, if it has one
+func Preferences(store CredentialStoreInterface) (PreferenceStore, error) {
+	prefs, ok := store.(PreferenceStore)
+	if !ok {
+		return nil, ErrPreferencesUnsupported
+	}
+	return prefs, nil
+}
+
+// GetPreferences returns a user's default workspace and aliases
+func (s *CredentialStore) GetPreferences(userID string) (*models.WorkspacePreferences, error) {
+	prefs := &models.WorkspacePreferences{Aliases: make(map[string]string)}
+
+	err := s.db.QueryRow(`
+		SELECT default_workspace_id FROM workspace_preferences WHERE user_id = $1
+	`, userID).Scan(&prefs.DefaultWorkspace)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT alias, workspace_id FROM workspace_aliases WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var alias, workspaceID string
+		if err := rows.Scan(&alias, &workspaceID); err != nil {
+			return nil, err
+		}
+		prefs.Aliases[alias] = workspaceID
+	}
+
+	return prefs, rows.Err()
+}
+
+// SetDefaultWorkspace sets or clears a user's default workspace
+func (s *CredentialStore) SetDefaultWorkspace(userID, workspaceID string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO workspace_preferences (user_id, default_workspace_id, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			default_workspace_id = EXCLUDED.default_workspace_id,
+			updated_at = EXCLUDED.updated_at
+	`, userID, workspaceID)
+	return err
+}
+
+// SetWorkspaceAlias points an alias at a workspace, or removes it
+func (s *CredentialStore) SetWorkspaceAlias(userID, alias, workspaceID string) error {
+	if workspaceID == "" {
+		_, err := s.db.Exec(`DELETE FROM workspace_aliases WHERE user_id = $1 AND alias = $2`, userID, alias)
+		return err
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO workspace_aliases (user_id, alias, workspace_id) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, alias) DO UPDATE SET workspace_id = EXCLUDED.workspace_id
+	`, userID, alias, workspaceID)
+	return err
+}
+
+// GetPreferences returns the default workspace and aliases declared in the workspaces file
+func (s *FileCredentialStore) GetPreferences(userID string) (*models.WorkspacePreferences, error) {
+	prefs := &models.WorkspacePreferences{Aliases: make(map[string]string)}
+	for name, ws := range s.workspaces {
+		if ws.Default {
+			prefs.DefaultWorkspace = name
+		}
+		for _, alias := range ws.Aliases {
+			prefs.Aliases[alias] = name
+		}
+	}
+	return prefs, nil
+}
+
+// SetDefaultWorkspace is not supported for file-based storage (read-only)
+func (s *FileCredentialStore) SetDefaultWorkspace(userID, workspaceID string) error {
+	return fmt.Errorf("file-based credential store is read-only; set \"default\": true in the workspaces file")
+}
+
+// SetWorkspaceAlias is not supported for file-based storage (read-only)
+func (s *FileCredentialStore) SetWorkspaceAlias(userID, alias, workspaceID string) error {
+	return fmt.Errorf("file-based credential store is read-only; add \"aliases\" in the workspaces file")
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync/atomic"
 )
 
 // Server handles MCP protocol communication over stdio
 type Server struct {
-	tools []Tool
+	tools        []Tool
+	toolFilter   func([]Tool) []Tool
+	toolsChanged atomic.Bool
 }
 
 // NewServer creates a new MCP server
@@ -25,6 +28,16 @@ func (s *Server) RegisterTool(tool Tool) {
 	s.tools = append(s.tools, tool)
 }
 
+// SetToolFilter sets a function that adjusts the registered tools each time they are listed
+func (s *Server) SetToolFilter(filter func([]Tool) []Tool) {
+	s.toolFilter = filter
+}
+
+// NotifyToolsChanged tells the client to list the tools again after the current response
+func (s *Server) NotifyToolsChanged() {
+	s.toolsChanged.Store(true)
+}
+
 // Start starts the MCP server on stdio
 func (s *Server) Start(handler func(ToolCall) (ToolResult, error)) error {
 	scanner := bufio.NewScanner(os.Stdin)
@@ -72,6 +85,16 @@ func (s *Server) Start(handler func(ToolCall) (ToolResult, error)) error {
 		responseBytes, _ := json.Marshal(response)
 		writer.Write(responseBytes)
 		writer.WriteString("\n")
+
+		if s.toolsChanged.Swap(false) {
+			notification, _ := json.Marshal(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "notifications/tools/list_changed",
+			})
+			writer.Write(notification)
+			writer.WriteString("\n")
+		}
+
 		writer.Sync()
 	}
 
@@ -83,7 +106,9 @@ func (s *Server) handleInitialize(request map[string]interface{}) map[string]int
 		"result": map[string]interface{}{
 			"protocolVersion": "2024-11-05",
 			"capabilities": map[string]interface{}{
-				"tools": map[string]interface{}{},
+				"tools": map[string]interface{}{
+					"listChanged": true,
+				},
 			},
 			"serverInfo": map[string]interface{}{
 				"name":    "trilix-atlassian-mcp-server",
@@ -94,9 +119,14 @@ func (s *Server) handleInitialize(request map[string]interface{}) map[string]int
 }
 
 func (s *Server) handleListTools() map[string]interface{} {
+	tools := s.tools
+	if s.toolFilter != nil {
+		tools = s.toolFilter(tools)
+	}
+
 	return map[string]interface{}{
 		"result": map[string]interface{}{
-			"tools": s.tools,
+			"tools": tools,
 		},
 	}
 }
@@ -31,7 +31,7 @@ Start-Sleep -Seconds 1
 
 # Start MCP Server
 Write-Host "Starting MCP Server..." -ForegroundColor Green
-Start-Process -FilePath $goPath -ArgumentList "run","main.go" -WorkingDirectory "$projectRoot\cmd\mcp-server" -WindowStyle Hidden
+Start-Process -FilePath $goPath -ArgumentList "run","." -WorkingDirectory "$projectRoot\cmd\mcp-server" -WindowStyle Hidden
 Start-Sleep -Seconds 2
 
 Write-Host ""